@@ -0,0 +1,57 @@
+package cleanup
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	log "github.com/sirupsen/logrus"
+)
+
+type ComponentKind string
+
+const (
+	MainEngines ComponentKind = "main-engine"
+	Generators  ComponentKind = "generator"
+	Boilers     ComponentKind = "boiler"
+)
+
+// PropertyCalc interface, but with the vessel anatomy that is loaded for
+// the post-vessel-anatomy stage
+type propertyAnatomy interface {
+	calcapi.PropertyCalc
+	ComponentNumbers(kind ComponentKind) []int
+}
+
+// Ships already logged as having no anatomy, so it's logged once rather
+// than per point
+var noAnatomyShips sync.Map
+
+// componentNumbers are the numbers of the ship's components of a kind, from
+// its vessel anatomy. Without the anatomy there's nothing to go on, so no
+// components are returned rather than guessing at them.
+func componentNumbers(pc calcapi.PropertyCalc, kind ComponentKind) []int {
+	if anatomy, ok := pc.(propertyAnatomy); ok {
+		return anatomy.ComponentNumbers(kind)
+	}
+	if _, logged := noAnatomyShips.LoadOrStore(pc.GetShip().ID, true); !logged {
+		log.Debugf("Somehow unable to convert to propertyAnatomy type for ship %d, skipping per component funcs", pc.GetShip().ID)
+	}
+	return nil
+}
+
+// ComponentLabel fills in the component number for {n} in a label template
+func ComponentLabel(template string, n int) string {
+	return strings.ReplaceAll(template, "{n}", strconv.Itoa(n))
+}
+
+// ForEachComponent runs fn for each of the ship's components of a kind. It
+// needs the vessel anatomy, so only works in PostVesselAnatomyStage.
+func ForEachComponent(kind ComponentKind, fn func(pc calcapi.PropertyCalc, n int)) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		for _, n := range componentNumbers(pc, kind) {
+			fn(pc, n)
+		}
+	}
+}
@@ -1,12 +1,10 @@
 package cleanup
 
 import (
-	"fmt"
 	"github.com/jinzhu/now"
 	"github.com/nautiluslabsco/ln/features/calc"
 	"github.com/nautiluslabsco/ln/features/calc/calcapi"
 	"github.com/nautiluslabsco/ln/shared/constants/labels"
-	"github.com/nautiluslabsco/ln/shared/constants/units"
 	"github.com/nautiluslabsco/ln/shared/models"
 	"github.com/nautiluslabsco/ln/shared/nmath"
 	"github.com/nautiluslabsco/null"
@@ -30,6 +28,8 @@ type CleanupFunc struct {
 	End           time.Time
 	Unconditional bool
 	Stage         Stage
+	Area          Geofence // Only applies while the position is inside, if set
+	Condition     func(calcapi.PropertyCalc) bool
 	CalcFunc      func(calcapi.PropertyCalc)
 	CleanFunc     func(propertyClean)
 }
@@ -44,7 +44,12 @@ type propertyClean interface {
 
 func CleanupFuncs(stage Stage, onlyUnconditional bool) []func(calcapi.PropertyCalc) {
 	var calcFuncsToRun []func(calcapi.PropertyCalc)
-	for _, cleanupFunc := range cleanupFuncs {
+	// Mappings go first so the rules see the mapped labels
+	var funcs []CleanupFunc
+	for _, m := range tagMappings {
+		funcs = append(funcs, m.cleanupFunc())
+	}
+	for _, cleanupFunc := range append(funcs, cleanupFuncs...) {
 		if onlyUnconditional && !cleanupFunc.Unconditional || stage != cleanupFunc.Stage {
 			continue
 		}
@@ -76,6 +81,12 @@ func (cfunc CleanupFunc) calcFunc() func(calcapi.PropertyCalc) {
 		}
 
 		if cfunc.Start.Before(pc.Time()) && endTime.After(pc.Time()) {
+			if cfunc.Area != nil && !InsideGeofence(cfunc.Area)(pc) {
+				return
+			}
+			if cfunc.Condition != nil && !cfunc.Condition(pc) {
+				return
+			}
 			log.Debugf("Cleaning up data on %s for ship %d because of %s", pc.Time().Format(time.RFC3339), pc.GetShip().ID, cfunc.Issue)
 			if cfunc.CalcFunc != nil {
 				cfunc.CalcFunc(pc)
@@ -139,22 +150,17 @@ func OverrideLatLonSign(pc calcapi.PropertyCalc) {
 		return
 	}
 
-	if noonLat.Value() < 0 && pos.Latitude > 0.0 {
-		pos.Latitude *= -1
-	}
-	if noonLon.Value() < 0 && pos.Longitude > 0.0 {
-		pos.Longitude *= -1
+	lat, lon := pos.Latitude, NormalizeLongitude(pos.Longitude)
+	if noonLat.Value() < 0 && lat > 0.0 {
+		lat *= -1
 	}
-	pc.SetPosition(null.FloatFrom(pos.Latitude), null.FloatFrom(pos.Longitude))
-}
-
-func OverrideChevronGeneratorPower(pc calcapi.PropertyCalc) {
-	for i := 1; i < 5; i++ {
-		mgPower := pc.GetNullableProperty(fmt.Sprintf("M/G %d Power", i))
-		if mgPower.Present() {
-			pc.SetPropertyWithUnit(fmt.Sprintf("Generator %d Power", i), mgPower.Value(), units.KiloWatts.String())
-		}
+	// Near the antimeridian a positive sensor longitude can already be on
+	// the noon report's side, so only flip when that brings it closer
+	refLon := NormalizeLongitude(noonLon.Value())
+	if refLon < 0 && lon > 0.0 && math.Abs(LongitudeDelta(refLon, -lon)) < math.Abs(LongitudeDelta(refLon, lon)) {
+		lon *= -1
 	}
+	pc.SetPosition(null.FloatFrom(lat), null.FloatFrom(lon))
 }
 
 func NegateLatitude(pc calcapi.PropertyCalc) {
@@ -166,8 +172,16 @@ func NegateLatitude(pc calcapi.PropertyCalc) {
 }
 func NegateLongitude(pc calcapi.PropertyCalc) {
 	pos := pc.Position()
-	//pc.SetNullableProperty("(Noon) Longitude", null.FloatFrom(-1*pc.GetNullableProperty("(Noon) Longitude")))
-	pc.SetPosition(null.FloatFrom(pos.Longitude), null.FloatFrom(-pos.Longitude))
+	if pos == nil {
+		return
+	}
+	pc.SetPosition(null.FloatFrom(pos.Latitude), null.FloatFrom(NormalizeLongitude(-pos.Longitude)))
+}
+
+func CopyPreviousPosition(pc calcapi.PropertyCalc) {
+	if prev := pc.PreviousPosition(); prev != nil {
+		pc.SetPosition(null.FloatFrom(prev.Latitude), null.FloatFrom(NormalizeLongitude(prev.Longitude)))
+	}
 }
 
 
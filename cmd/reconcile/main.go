@@ -0,0 +1,81 @@
+// Command reconcile compares sensor values with noon reports over each
+// noon-to-noon period of a CSV export, printing the periods that disagree
+// and a drafted cleanup rule for each where there's an obvious fix.
+//
+//	reconcile -ship 181 -check total:ME_HSFO_t_h:0.1 -check position::30 export.csv
+//
+// The export has a time column followed by one column per label, with the
+// noon report labels alongside the sensor labels.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nautiluslabsco/ln/features/cleanup"
+	log "github.com/sirupsen/logrus"
+)
+
+type checkFlags []cleanup.ReconciliationCheck
+
+func (c *checkFlags) String() string {
+	return fmt.Sprint(*c)
+}
+
+// Set parses kind:label:tolerance, e.g. mean:Shaft Speed:0.05
+func (c *checkFlags) Set(value string) error {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return fmt.Errorf("expected kind:label:tolerance, got %q", value)
+	}
+	tolerance, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return err
+	}
+	kind := cleanup.ReconcileKind(parts[0])
+	switch kind {
+	case cleanup.ReconcileTotal, cleanup.ReconcileMean, cleanup.ReconcilePosition:
+	default:
+		return fmt.Errorf("unknown check kind %q", parts[0])
+	}
+	*c = append(*c, cleanup.ReconciliationCheck{Label: parts[1], Kind: kind, Tolerance: tolerance})
+	return nil
+}
+
+func main() {
+	var checks checkFlags
+	shipID := flag.Int64("ship", 0, "ship ID the export is for")
+	flag.Var(&checks, "check", "kind:label:tolerance, with kind one of total, mean or position (repeatable)")
+	flag.Parse()
+	if len(checks) == 0 || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var in io.Reader = os.Stdin
+	if flag.NArg() == 1 {
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	points, err := cleanup.ReadReconciliationCSV(in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	discrepancies := cleanup.Reconcile(*shipID, points, checks)
+	for _, d := range discrepancies {
+		fmt.Println(d)
+		if d.Rule != "" {
+			fmt.Println(d.Rule)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d discrepancies in %d points\n", len(discrepancies), len(points))
+}
@@ -0,0 +1,79 @@
+package cleanup
+
+import (
+	"math"
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	"github.com/nautiluslabsco/ln/shared/constants/labels"
+	log "github.com/sirupsen/logrus"
+)
+
+const rescaledMark = "Rescaled"
+
+// Largest power of ten a noon report value is expected to be out by
+const maxDecimalShift = 4
+
+// A noon report covers the day before it
+const noonReportSpan = 24 * time.Hour
+
+// decimalReference is the median sensor value for label over the day the
+// noon report covers, or failing that the median of the noon reports over
+// history. The median keeps a report made while manoeuvring at noon from
+// being compared with the slow hourly value.
+func decimalReference(pc calcapi.PropertyCalc, label string, history time.Duration) (float64, bool) {
+	var sensor []float64
+	for idx := pc.FeatureIndex(); hasFeature(pc, idx) && pc.Time().Sub(featureTime(pc, idx)) < noonReportSpan; idx-- {
+		if v, ok := featureValue(pc, label, idx); ok && v != 0 {
+			sensor = append(sensor, v)
+		}
+	}
+	if len(sensor) > 0 {
+		return median(sensor), true
+	}
+	var recent []float64
+	for idx := pc.FeatureIndex() - 1; withinSpan(pc, idx, history); idx-- {
+		if v, ok := featureValue(pc, labels.Noon(label), idx); ok && v != 0 && !marked(pc, labels.Noon(label), rescaledMark, idx) {
+			recent = append(recent, v)
+		}
+	}
+	if len(recent) == 0 {
+		return 0, false
+	}
+	return median(recent), true
+}
+
+// RescaleDecimalShift fixes a noon report value for label that is out by a
+// power of ten, e.g. a shaft speed of 7534 that should be 75.34. The value
+// is compared with the sensor's median over the day, or recent noon reports when there
+// isn't one, and rescaled when within tolerance of a power of ten off. The
+// factor applied is recorded on the point.
+func RescaleDecimalShift(label string, history time.Duration, tolerance float64) func(calcapi.PropertyCalc) {
+	noonLabel := labels.Noon(label)
+	return func(pc calcapi.PropertyCalc) {
+		v := pc.GetNullableProperty(noonLabel)
+		if v.Absent() || v.Value() == 0 {
+			return
+		}
+		ref, ok := decimalReference(pc, label, history)
+		if !ok {
+			return
+		}
+		ratio := v.Value() / ref
+		if ratio <= 0 {
+			return
+		}
+		shift := math.Round(math.Log10(ratio))
+		if shift == 0 || math.Abs(shift) > maxDecimalShift {
+			return
+		}
+		factor := math.Pow(10, -shift)
+		if math.Abs(ratio*factor-1) > tolerance {
+			return
+		}
+
+		log.Infof("Rescaled %s on %s for ship %d from %v to %v", noonLabel, pc.Time().Format(time.RFC3339), pc.GetShip().ID, v.Value(), v.Value()*factor)
+		pc.SetProperty(noonLabel, v.Value()*factor)
+		pc.SetProperty(cleanupLabel(noonLabel, rescaledMark), factor)
+	}
+}
@@ -0,0 +1,124 @@
+package cleanup
+
+import (
+	"math"
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	"github.com/nautiluslabsco/ln/shared/constants/labels"
+)
+
+const (
+	outOfRangeMark   = "Out Of Range"
+	recomputedMark   = "Recomputed"
+	changedAtSeaMark = "Changed At Sea"
+)
+
+var draftLabels = []string{labels.DraftFwd, labels.DraftAft, labels.DraftMid1, labels.DraftMid2}
+
+// DraftParticulars are the ship's drafts from its particulars, in metres
+type DraftParticulars struct {
+	BallastDraft   float64
+	DesignDraft    float64
+	ScantlingDraft float64 // The design draft is the upper limit when unknown
+	MaxTrim        float64 // Largest trim either way
+	Tolerance      float64 // Allowance for sensor noise and squat
+	// Mean draft changes at sea up to MaxChangeAtSea, e.g. from ballasting,
+	// are accepted. Larger ones are flagged unless there was a port call
+	// within Window before.
+	MaxChangeAtSea float64
+	Window         time.Duration
+}
+
+func (p DraftParticulars) maxDraft() float64 {
+	if p.ScantlingDraft > 0 {
+		return p.ScantlingDraft
+	}
+	return p.DesignDraft
+}
+
+// draftRange is the physical range of a draft label; the end drafts may be
+// off the mean by up to half the trim
+func (p DraftParticulars) draftRange(label string) (float64, float64) {
+	margin := p.Tolerance
+	if label == labels.DraftFwd || label == labels.DraftAft {
+		margin += p.MaxTrim / 2
+	}
+	return p.BallastDraft - margin, p.maxDraft() + margin
+}
+
+func meanDraftAt(pc calcapi.PropertyCalc, idx int) (float64, bool) {
+	sum, n := 0.0, 0
+	for _, label := range draftLabels {
+		if v, ok := featureValue(pc, label, idx); ok {
+			sum += v
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
+}
+
+// changedAtSea compares the mean draft with its median over the window,
+// as long as the ship has been at sea for all of it. Any point that isn't
+// at sea counts as a possible port call, since cargo can also be worked
+// during stops too short to come out as InPortMode, such as lightering.
+func (p DraftParticulars) changedAtSea(pc calcapi.PropertyCalc) bool {
+	if p.Window == 0 || OperationalModeOf(pc) != AtSeaMode {
+		return false
+	}
+	current, ok := meanDraftAt(pc, pc.FeatureIndex())
+	if !ok {
+		return false
+	}
+	var previous []float64
+	for idx := pc.FeatureIndex() - 1; withinSpan(pc, idx, p.Window); idx-- {
+		if operationalModeAt(pc, idx) != AtSeaMode {
+			return false
+		}
+		if v, ok := meanDraftAt(pc, idx); ok {
+			previous = append(previous, v)
+		}
+	}
+	return len(previous) > 0 && math.Abs(current-median(previous)) > p.MaxChangeAtSea
+}
+
+// DraftCheck applies the action to drafts outside the ship's physical
+// range and to all of them when they change at sea with no port call, then
+// recomputes the trim from the end drafts when it disagrees with them
+func DraftCheck(particulars DraftParticulars, action Action) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		valid := map[string]float64{}
+		for _, label := range draftLabels {
+			v := pc.GetNullableProperty(label)
+			if v.Absent() {
+				continue
+			}
+			if lower, upper := particulars.draftRange(label); v.Value() < lower || v.Value() > upper {
+				applyAction(pc, label, action, outOfRangeMark)
+				continue
+			}
+			valid[label] = v.Value()
+		}
+
+		if particulars.changedAtSea(pc) {
+			for label := range valid {
+				applyAction(pc, label, action, changedAtSeaMark)
+			}
+			return
+		}
+
+		fwd, fwdOk := valid[labels.DraftFwd]
+		aft, aftOk := valid[labels.DraftAft]
+		if !fwdOk || !aftOk {
+			return
+		}
+		trim := aft - fwd
+		if v := pc.GetNullableProperty(labels.Trim); v.Present() && math.Abs(v.Value()-trim) > particulars.Tolerance {
+			pc.SetProperty(labels.Trim, trim)
+			markPoint(pc, labels.Trim, recomputedMark)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package cleanup
+
+import (
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	"github.com/nautiluslabsco/ln/shared/models"
+	"github.com/nautiluslabsco/null"
+)
+
+var fakeStart = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fakeCalc is a series of features, hourly unless times are given. Writes
+// go to the current feature, so later features see the edits made to
+// earlier ones. Reading a feature that isn't there panics, so a check that
+// reads past either end of the series fails its test.
+type fakeCalc struct {
+	shipID   int64
+	idx      int
+	features []map[string]float64
+	times    []time.Time
+}
+
+func newFakeCalc(features ...map[string]float64) *fakeCalc {
+	for i, f := range features {
+		if f == nil {
+			features[i] = map[string]float64{}
+		}
+	}
+	return &fakeCalc{features: features}
+}
+
+// run applies fn to every feature in order, the way the cleanup stages do
+func (c *fakeCalc) run(fn func(calcapi.PropertyCalc)) *fakeCalc {
+	for c.idx = range c.features {
+		fn(c)
+	}
+	return c
+}
+
+func (c *fakeCalc) value(label string, idx int) models.Value {
+	if v, ok := c.features[idx][label]; ok {
+		return models.SomeValue(v)
+	}
+	return models.NullValue()
+}
+
+func (c *fakeCalc) GetProperty(label string) float64 { return c.features[c.idx][label] }
+func (c *fakeCalc) GetNullableProperty(label string) models.Value {
+	return c.value(label, c.idx)
+}
+func (c *fakeCalc) GetPreviousNullableProperty(label string) models.Value {
+	if c.idx == 0 {
+		return models.NullValue()
+	}
+	return c.value(label, c.idx-1)
+}
+func (c *fakeCalc) GetNullablePropertyFromFeature(label string, idx int) models.Value {
+	return c.value(label, idx)
+}
+func (c *fakeCalc) GetUnitForProperty(string) string { return "" }
+func (c *fakeCalc) HasError() bool                   { return false }
+func (c *fakeCalc) Time() time.Time                  { return c.FeatureTime(c.idx) }
+func (c *fakeCalc) FeatureIndex() int                { return c.idx }
+func (c *fakeCalc) GetShip() *models.Ship            { return &models.Ship{ID: c.shipID} }
+func (c *fakeCalc) FeatureCount() int                { return len(c.features) }
+func (c *fakeCalc) FeatureTime(idx int) time.Time {
+	if c.times != nil {
+		return c.times[idx]
+	}
+	return fakeStart.Add(time.Duration(idx) * time.Hour)
+}
+
+func (c *fakeCalc) positionAt(idx int) *models.Position {
+	if idx < 0 {
+		return nil
+	}
+	lat, latOk := c.features[idx][latitudeLabel]
+	lon, lonOk := c.features[idx][longitudeLabel]
+	if !latOk || !lonOk {
+		return nil
+	}
+	return &models.Position{Latitude: lat, Longitude: lon}
+}
+
+func (c *fakeCalc) Position() *models.Position         { return c.positionAt(c.idx) }
+func (c *fakeCalc) PreviousPosition() *models.Position { return c.positionAt(c.idx - 1) }
+
+func (c *fakeCalc) SetProperty(label string, v float64) { c.features[c.idx][label] = v }
+func (c *fakeCalc) SetPropertyWithUnit(label string, v float64, _ string) {
+	c.SetProperty(label, v)
+}
+func (c *fakeCalc) SetNullableProperty(label string, v models.Value) {
+	if v.Present() {
+		c.features[c.idx][label] = v.Value()
+		return
+	}
+	delete(c.features[c.idx], label)
+}
+func (c *fakeCalc) SetPosition(lat, lon null.Float) {
+	c.SetNullableProperty(latitudeLabel, nullableValue(lat))
+	c.SetNullableProperty(longitudeLabel, nullableValue(lon))
+}
+
+func nullableValue(f null.Float) models.Value {
+	if !f.Valid {
+		return models.NullValue()
+	}
+	return models.SomeValue(f.Float64)
+}
@@ -0,0 +1,102 @@
+package cleanup
+
+import (
+	"math"
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	"github.com/nautiluslabsco/ln/shared/constants/labels"
+	"github.com/nautiluslabsco/null"
+	log "github.com/sirupsen/logrus"
+)
+
+// Anything faster than this between consecutive fixes is treated as a jump
+const maxPlausibleSpeedKnots = 40.0
+
+// PositionSource is one candidate in a position fallback chain
+type PositionSource struct {
+	Name     string
+	position func(pc calcapi.PropertyCalc) (LatLon, bool)
+}
+
+func PrimaryGPS() PositionSource {
+	return PositionSource{Name: "Primary GPS", position: currentPosition}
+}
+
+func LabelledPosition(name, latLabel, lonLabel string) PositionSource {
+	return PositionSource{
+		Name: name,
+		position: func(pc calcapi.PropertyCalc) (LatLon, bool) {
+			lat, lon := pc.GetNullableProperty(latLabel), pc.GetNullableProperty(lonLabel)
+			if lat.Absent() || lon.Absent() {
+				return LatLon{}, false
+			}
+			return LatLon{Latitude: lat.Value(), Longitude: lon.Value()}, true
+		},
+	}
+}
+
+func SpireAIS() PositionSource {
+	return LabelledPosition("Spire AIS", labels.AisLatitude, labels.AisLongitude)
+}
+
+func VoyageLocation() PositionSource {
+	return LabelledPosition("Voyage Location", "Voyage Location latitude", "Voyage Location longitude")
+}
+
+// LastKnownPosition holds the most recent position no older than maxAge
+func LastKnownPosition(maxAge time.Duration) PositionSource {
+	return PositionSource{
+		Name: "Last Known Position",
+		position: func(pc calcapi.PropertyCalc) (LatLon, bool) {
+			for idx := pc.FeatureIndex() - 1; withinSpan(pc, idx, maxAge); idx-- {
+				if p, ok := featurePosition(pc, idx); ok && validCoordinates(p) {
+					return p, true
+				}
+			}
+			return LatLon{}, false
+		},
+	}
+}
+
+func validCoordinates(p LatLon) bool {
+	if math.IsNaN(p.Latitude) || math.IsNaN(p.Longitude) {
+		return false
+	}
+	if math.Abs(p.Latitude) > 90 || math.Abs(p.Longitude) > 180 {
+		return false
+	}
+	// Null island is what most sensors report when they have no fix
+	return p.Latitude != 0 || p.Longitude != 0
+}
+
+func validPosition(pc calcapi.PropertyCalc, p LatLon) bool {
+	if !validCoordinates(p) {
+		return false
+	}
+	prev, ok := featurePosition(pc, pc.FeatureIndex()-1)
+	if !ok || !validCoordinates(prev) {
+		return true
+	}
+	hours := hoursBetween(pc, pc.FeatureIndex()-1, pc.FeatureIndex())
+	return hours > 0 && DistanceNM(p, prev)/hours <= maxPlausibleSpeedKnots
+}
+
+// PositionFallbackChain sets the position from the first source whose
+// candidate passes validation and records which source was used. When
+// no source has a valid position the point is left as it is.
+func PositionFallbackChain(sources ...PositionSource) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		for _, source := range sources {
+			p, ok := source.position(pc)
+			if !ok || !validPosition(pc, p) {
+				continue
+			}
+			p = p.Normalized()
+			pc.SetPosition(null.FloatFrom(p.Latitude), null.FloatFrom(p.Longitude))
+			markPoint(pc, "Position Source", source.Name)
+			return
+		}
+		log.Debugf("No valid position source on %s for ship %d", pc.Time().Format(time.RFC3339), pc.GetShip().ID)
+	}
+}
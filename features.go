@@ -0,0 +1,181 @@
+package cleanup
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	latitudeLabel  = "Latitude"
+	longitudeLabel = "Longitude"
+)
+
+// PropertyCalc interface, but with the length and timestamps of the series
+// of features it runs over, which checks looking at neighbouring features
+// need to stay within the series and go by the real time between features
+type propertySeries interface {
+	calcapi.PropertyCalc
+	FeatureCount() int
+	FeatureTime(idx int) time.Time
+}
+
+// So a calc without the series is only logged once rather than per point
+var noSeriesLogged sync.Once
+
+// hasFeature reports whether there is a feature at idx. For a calc that
+// can't say how long its series is, only the current feature is known to
+// be there, so no other feature is read.
+func hasFeature(pc calcapi.PropertyCalc, idx int) bool {
+	if idx == pc.FeatureIndex() {
+		return true
+	}
+	series, ok := pc.(propertySeries)
+	if !ok {
+		noSeriesLogged.Do(func() {
+			log.Debugf("Somehow unable to convert %T to propertySeries type, only reading the current feature", pc)
+		})
+		return false
+	}
+	return idx >= 0 && idx < series.FeatureCount()
+}
+
+// featureTime is the timestamp of the feature at idx, which must exist
+func featureTime(pc calcapi.PropertyCalc, idx int) time.Time {
+	if idx == pc.FeatureIndex() {
+		return pc.Time()
+	}
+	return pc.(propertySeries).FeatureTime(idx)
+}
+
+// withinSpan reports whether there is a feature at idx no further than d
+// from the current one
+func withinSpan(pc calcapi.PropertyCalc, idx int, d time.Duration) bool {
+	if !hasFeature(pc, idx) {
+		return false
+	}
+	offset := featureTime(pc, idx).Sub(pc.Time())
+	return -d <= offset && offset <= d
+}
+
+// hoursBetween is the time in hours from the feature at from to the one at to
+func hoursBetween(pc calcapi.PropertyCalc, from, to int) float64 {
+	return featureTime(pc, to).Sub(featureTime(pc, from)).Hours()
+}
+
+// timeFraction is how far the feature at idx is from the one at from
+// towards the one at to, going by their timestamps
+func timeFraction(pc calcapi.PropertyCalc, from, idx, to int) float64 {
+	return hoursBetween(pc, from, idx) / hoursBetween(pc, from, to)
+}
+
+func featureValue(pc calcapi.PropertyCalc, label string, idx int) (float64, bool) {
+	if !hasFeature(pc, idx) {
+		return 0, false
+	}
+	v := pc.GetNullableProperty(label)
+	if idx != pc.FeatureIndex() {
+		v = pc.GetNullablePropertyFromFeature(label, idx)
+	}
+	if v.Absent() {
+		return 0, false
+	}
+	return v.Value(), true
+}
+
+// originalValue is the value the sensor reported, from before any check
+// nulled or replaced it
+func originalValue(pc calcapi.PropertyCalc, label string, idx int) (float64, bool) {
+	if v, ok := featureValue(pc, cleanupLabel(label, originalMark), idx); ok {
+		return v, true
+	}
+	return featureValue(pc, label, idx)
+}
+
+func featurePosition(pc calcapi.PropertyCalc, idx int) (LatLon, bool) {
+	if idx == pc.FeatureIndex() {
+		return currentPosition(pc)
+	}
+	lat, latOk := featureValue(pc, latitudeLabel, idx)
+	lon, lonOk := featureValue(pc, longitudeLabel, idx)
+	if !latOk || !lonOk {
+		return LatLon{}, false
+	}
+	return LatLon{Latitude: lat, Longitude: lon}, true
+}
+
+func currentPosition(pc calcapi.PropertyCalc) (LatLon, bool) {
+	pos := pc.Position()
+	if pos == nil {
+		return LatLon{}, false
+	}
+	return LatLon{Latitude: pos.Latitude, Longitude: pos.Longitude}, true
+}
+
+// unchangedRunBounds finds the run of features around the current one
+// holding exactly the same original value, looking at most limit either
+// way. Going by the original values means a run is still found once the
+// features at its head have been nulled.
+func unchangedRunBounds(pc calcapi.PropertyCalc, label string, limit time.Duration) (int, int, bool) {
+	idx := pc.FeatureIndex()
+	v, ok := originalValue(pc, label, idx)
+	if !ok {
+		return 0, 0, false
+	}
+	bounds := [2]int{idx, idx}
+	for b, dir := range []int{-1, 1} {
+		for i := idx + dir; withinSpan(pc, i, limit); i += dir {
+			if w, ok := originalValue(pc, label, i); !ok || w != v {
+				break
+			}
+			bounds[b] = i
+		}
+	}
+	return bounds[0], bounds[1], true
+}
+
+// changedBetween reports whether label takes more than one original value
+// over the features first to last
+func changedBetween(pc calcapi.PropertyCalc, label string, first, last int) bool {
+	var seen float64
+	found := false
+	for i := first; i <= last; i++ {
+		v, ok := originalValue(pc, label, i)
+		if !ok {
+			continue
+		}
+		if found && v != seen {
+			return true
+		}
+		seen, found = v, true
+	}
+	return false
+}
+
+// sustained reports whether the run of features around the current one for
+// which holds is true lasts at least minRun, from its first feature to its last
+func sustained(pc calcapi.PropertyCalc, minRun time.Duration, holds func(idx int) bool) bool {
+	return sustainedAt(pc, pc.FeatureIndex(), minRun, holds)
+}
+
+// sustainedAt is sustained for the run around the feature at idx
+func sustainedAt(pc calcapi.PropertyCalc, idx int, minRun time.Duration, holds func(idx int) bool) bool {
+	first, last := idx, idx
+	lasts := func() bool {
+		return featureTime(pc, last).Sub(featureTime(pc, first)) >= minRun
+	}
+	for !lasts() && hasFeature(pc, first-1) && holds(first-1) {
+		first--
+	}
+	for !lasts() && hasFeature(pc, last+1) && holds(last+1) {
+		last++
+	}
+	return lasts()
+}
+
+func marked(pc calcapi.PropertyCalc, label, mark string, idx int) bool {
+	_, ok := featureValue(pc, cleanupLabel(label, mark), idx)
+	return ok
+}
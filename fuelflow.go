@@ -0,0 +1,68 @@
+package cleanup
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+)
+
+var fuelGrades = []string{"HSFO", "LSFO", "MDO", "MGO"}
+
+// FuelFlowLabels are the per-grade flow labels of a fuel system, e.g. ME_HSFO_t_h
+func FuelFlowLabels(system string) []string {
+	flowLabels := make([]string, 0, len(fuelGrades))
+	for _, grade := range fuelGrades {
+		flowLabels = append(flowLabels, fmt.Sprintf("%s_%s_t_h", system, grade))
+	}
+	return flowLabels
+}
+
+// EngineSpec describes the consumer behind a set of fuel flow labels.
+// Flows are in t/h and power in kW.
+type EngineSpec struct {
+	FlowLabels      []string
+	PowerLabel      string // Skips the consistency check with power when empty
+	RatedPower      float64
+	MaxSFOC         float64 // g/kWh
+	IdleConsumption float64 // t/h with no load
+}
+
+func (spec EngineSpec) maxFlow(power float64) float64 {
+	return spec.IdleConsumption + math.Max(power, 0)*spec.MaxSFOC/1e6
+}
+
+// FuelFlowPlausibility checks flows are non-negative, within what the
+// engine can burn at its rating, and that together they are no more than
+// the power produced could account for
+func FuelFlowPlausibility(spec EngineSpec, action Action) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		var present []string
+		total := 0.0
+		for _, label := range spec.FlowLabels {
+			v := pc.GetNullableProperty(label)
+			if v.Absent() {
+				continue
+			}
+			switch {
+			case v.Value() < 0:
+				applyAction(pc, label, action, "Negative")
+			case v.Value() > spec.maxFlow(spec.RatedPower):
+				applyAction(pc, label, action, "Above Rated")
+			default:
+				present = append(present, label)
+				total += v.Value()
+			}
+		}
+
+		if spec.PowerLabel == "" || len(present) == 0 {
+			return
+		}
+		power := pc.GetNullableProperty(spec.PowerLabel)
+		if power.Present() && total > spec.maxFlow(power.Value()) {
+			for _, label := range present {
+				applyAction(pc, label, action, "Inconsistent With Power")
+			}
+		}
+	}
+}
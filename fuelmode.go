@@ -0,0 +1,84 @@
+package cleanup
+
+import (
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+)
+
+const (
+	overlappingModeMark = "Overlapping Fuel Mode"
+	noModeMark          = "No Fuel Mode"
+	outsideModeMark     = "Flow Outside Fuel Mode"
+	reassignedMark      = "Reassigned"
+)
+
+// Fuels the Hoppe mode switch tags are reported for
+var hoppeFuels = []string{"ULSFO", "MGO", "MDO", "LSMGO", "LSHFO", "HFO"}
+
+// FuelMode pairs a mode flag, 1 while the fuel is in use, with the label
+// that fuel's flow is metered on
+type FuelMode struct {
+	Flag string
+	Flow string // Optional
+}
+
+// FuelModeGroup is a consumer's set of fuel modes, of which exactly one
+// should be active at a time
+type FuelModeGroup struct {
+	Name  string
+	Modes []FuelMode
+	// Move flow metered on an inactive fuel's label to the active fuel's
+	// label, instead of applying the action to it
+	Reassign bool
+}
+
+// FuelModeExclusivity checks that exactly one of the group's fuel modes is
+// active and that flow only shows up on the active fuel's label
+func FuelModeExclusivity(group FuelModeGroup, action Action) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		var active []FuelMode
+		reported := false
+		for _, mode := range group.Modes {
+			if v := pc.GetNullableProperty(mode.Flag); v.Present() {
+				reported = true
+				if v.Value() > 0.5 {
+					active = append(active, mode)
+				}
+			}
+		}
+
+		switch {
+		case !reported:
+			return
+		case len(active) == 0:
+			markPoint(pc, group.Name, noModeMark)
+			return
+		case len(active) > 1:
+			for _, mode := range active {
+				applyAction(pc, mode.Flag, action, overlappingModeMark)
+			}
+			return
+		}
+
+		target := active[0].Flow
+		for _, mode := range group.Modes {
+			if mode == active[0] || mode.Flow == "" {
+				continue
+			}
+			v := pc.GetNullableProperty(mode.Flow)
+			if v.Absent() || v.Value() <= 0 {
+				continue
+			}
+			if !group.Reassign || target == "" {
+				applyAction(pc, mode.Flow, action, outsideModeMark)
+				continue
+			}
+			moved := v.Value()
+			if t := pc.GetNullableProperty(target); t.Present() {
+				moved += t.Value()
+			}
+			pc.SetProperty(target, moved)
+			pc.SetProperty(mode.Flow, 0)
+			markPoint(pc, mode.Flow, reassignedMark)
+		}
+	}
+}
@@ -250,36 +250,6 @@ var cleanupFuncs = []CleanupFunc{
 		Stage:         PostVesselAnatomyStage,
 		CalcFunc:      OverrideLatLonSign,
 	},
-	{
-		Comment:       "Generator Power tags were changed",
-		Issue:         "DPI-922",
-		ShipID:        207,
-		Start:         parseTime("2020-01-01 00:00"),
-		End:           parseTime("2020-09-30 06:00"),
-		Unconditional: true,
-		Stage:         PostVesselAnatomyStage,
-		CalcFunc:      OverrideChevronGeneratorPower,
-	},
-	{
-		Comment:       "Generator Power tags were changed",
-		Issue:         "DPI-922",
-		ShipID:        896,
-		Start:         parseTime("2020-01-01 00:00"),
-		End:           parseTime("2020-09-13 16:00"),
-		Unconditional: true,
-		Stage:         PostVesselAnatomyStage,
-		CalcFunc:      OverrideChevronGeneratorPower,
-	},
-	{
-		Comment:       "Generator Power tags were changed",
-		Issue:         "DPI-922",
-		ShipID:        971,
-		Start:         parseTime("2020-01-01 00:00"),
-		End:           parseTime("2020-09-27 00:00"),
-		Unconditional: true,
-		Stage:         PostVesselAnatomyStage,
-		CalcFunc:      OverrideChevronGeneratorPower,
-	},
 	{
 		Comment:       "Position Sign tags not provided yet",
 		Issue:         "DPI-920",
@@ -525,24 +495,6 @@ var cleanupFuncs = []CleanupFunc{
 		Stage:         PreVesselAnatomyStage,
 		CalcFunc:      RemoveBadGPS,
 	},
-	{
-		Comment:       "Diamond Bulk Sincere Pisces - Alias alternative mode switch tags",
-		Issue:         "ENG-476",
-		ShipID:        calc.DbcSincerePisces,
-		Start:         time.Time{}, // Unbounded
-		End:           parseTime("2020-11-25 00:00"),
-		Unconditional: true,
-		Stage:         PreVesselAnatomyStage,
-		CalcFunc: func(pc calcapi.PropertyCalc) {
-			// Sets the shared Hoppe mode switch tags from the DBC exclusive mode switch tags
-			pc.SetNullableProperty("Main Engine on ULSFO", pc.GetNullableProperty("Main Engine using ULSFO"))
-			pc.SetNullableProperty("Main Engine on MGO", pc.GetNullableProperty("Main Engine using MGO"))
-			pc.SetNullableProperty("Main Engine on MDO", pc.GetNullableProperty("Main Engine using MDO"))
-			pc.SetNullableProperty("Main Engine on LSMGO", pc.GetNullableProperty("Main Engine using LSMGO"))
-			pc.SetNullableProperty("Main Engine on LSHFO", pc.GetNullableProperty("Main Engine using LSHFO"))
-			pc.SetNullableProperty("Main Engine on HFO", pc.GetNullableProperty("Main Engine using HFO"))
-		},
-	},
 	{
 		Comment:       "Hunter fallback to voyage location gps must run before weather service",
 		Issue:         "ENG-477",
@@ -662,12 +614,7 @@ var cleanupFuncs = []CleanupFunc{
 		End:           parseTime("2021-01-25 22:00:00"),
 		Unconditional: true,
 		Stage:         PreVesselAnatomyStage,
-		CalcFunc: func(pc calcapi.PropertyCalc) {
-			// simply copy last position
-			if prev := pc.PreviousPosition(); prev != nil {
-				pc.SetPosition(null.FloatFrom(prev.Latitude), null.FloatFrom(prev.Longitude))
-			}
-		},
+		CalcFunc:      CopyPreviousPosition,
 	},
 	{
 		Comment:       "nulling out lat/lon data from reports prior to sensor data",
@@ -687,15 +634,7 @@ var cleanupFuncs = []CleanupFunc{
 		End:           parseTime("2021-01-03 06:00"),
 		Unconditional: true,
 		Stage:         PreVesselAnatomyStage,
-		CalcFunc: func(pc calcapi.PropertyCalc) {
-			const genLshfoCons = "AE_LSFO_t_h"
-			prevGenCons := pc.GetPreviousNullableProperty(genLshfoCons)
-			nextGenCons := pc.GetNullablePropertyFromFeature(genLshfoCons, pc.FeatureIndex()+1)
-			if prevGenCons.Present() && nextGenCons.Present() {
-				currGenCons := models.SomeValue((prevGenCons.Value() + nextGenCons.Value()) / 2.0)
-				pc.SetNullableProperty(genLshfoCons, currGenCons)
-			}
-		},
+		CalcFunc:      ReplaceByInterpolation("AE_LSFO_t_h", LinearInterpolation, 2*time.Hour),
 	},
 	{
 		Comment:       "alias SOG with Observed Speed for VO",
@@ -1025,32 +964,6 @@ var cleanupFuncs = []CleanupFunc{
 		Stage:         PreVesselAnatomyStage,
 		CalcFunc:      calc.SetNull(labels.MainEngineFuelConsumption, labels.GeneratorFuelConsumption),
 	},
-	{
-		Comment:       "Hunter Freya - Use Deprecated Fuel Tag Prior to New Tag Addition",
-		Issue:         "ENG-1007",
-		ShipID:        calc.HunterFreya,
-		Start:         time.Time{}, // Unbounded
-		End:           parseTime("2021-05-27 00:00"),
-		Unconditional: true,
-		Stage:         PreVesselAnatomyStage,
-		CalcFunc: func(pc calcapi.PropertyCalc) {
-			// Sets the fuel consumption tag to the only tag previously available for these two ships to the standard tag and prevents double counting for the remainder of the data
-			pc.SetNullableProperty("Engines_Main_FO_Flow", pc.GetNullableProperty("Engines_Main_1_Fuel_Oil_System_FO_Inlet_Flow_Mass"))
-		},
-	},
-	{
-		Comment:       "Hunter Frigg - Use Deprecated Fuel Tag Prior to New Tag Addition",
-		Issue:         "ENG-1007",
-		ShipID:        calc.HunterFrigg,
-		Start:         time.Time{}, // Unbounded
-		End:           parseTime("2021-05-27 00:00"),
-		Unconditional: true,
-		Stage:         PreVesselAnatomyStage,
-		CalcFunc: func(pc calcapi.PropertyCalc) {
-			// Sets the fuel consumption tag to the only tag previously available for these two ships to the standard tag and prevents double counting for the remainder of the data
-			pc.SetNullableProperty("Engines_Main_FO_Flow", pc.GetNullableProperty("Engines_Main_1_Fuel_Oil_System_FO_Inlet_Flow_Mass"))
-		},
-	},
 	{
 		Comment:       "EPS - Pacific Gold weird spike",
 		Issue:         "ENG-924",
@@ -1123,17 +1036,9 @@ var cleanupFuncs = []CleanupFunc{
 		End:           parseTime("2022-05-31 13:00:00"),
 		Unconditional: true,
 		Stage:         PreVesselAnatomyStage,
-		CalcFunc:      calc.FallbackForZeroPosition("H2259.AIS_Latitude", "H2259.AIS_Longitude"),
-	},
-	{
-		Comment:       "Onboard AIS Fallback for BW Brussels",
-		Issue:         "DPI-1680",
-		ShipID:        351, //BW Brussels
-		Start:         time.Time{},
-		End:           parseTime("2022-05-31 13:00:00"),
-		Unconditional: true,
-		Stage:         PreVesselAnatomyStage,
-		CalcFunc:      calc.FallbackForZeroPosition("H2259.AIS_Latitude", "H2259.AIS_Longitude"),
+		CalcFunc: PositionFallbackChain(
+			PrimaryGPS(),
+			LabelledPosition("Onboard AIS", "H2259.AIS_Latitude", "H2259.AIS_Longitude")),
 	},
 	{
 		Comment:       "Spire AIS Fallback for BW Brussels",
@@ -1143,7 +1048,7 @@ var cleanupFuncs = []CleanupFunc{
 		End:           time.Time{},
 		Unconditional: true,
 		Stage:         PreVesselAnatomyStage,
-		CalcFunc:      calc.UseAISforPosition(labels.AisLatitude, labels.AisLongitude),
+		CalcFunc:      PositionFallbackChain(SpireAIS(), PrimaryGPS()),
 	},
 	{
 		Comment:       "Removed brussel bad GPS in June 2021",
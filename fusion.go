@@ -0,0 +1,259 @@
+package cleanup
+
+import (
+	"math"
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	"github.com/nautiluslabsco/ln/shared/constants/labels"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	oddOneOutMark    = "Odd One Out"
+	inconsistentMark = "Inconsistent"
+)
+
+type FusionMethod string
+
+const (
+	FuseMean   FusionMethod = "mean"
+	FuseMedian FusionMethod = "median"
+	FuseSum    FusionMethod = "sum"
+)
+
+// SensorGroup is a set of redundant or related sensors checked against
+// each other. A sensor that disagrees with the rest beyond the tolerance is
+// dropped, as long as the rest agree among themselves without it.
+type SensorGroup struct {
+	Name      string
+	Labels    []string
+	Tolerance float64 // In the labels' units
+	// Expected estimates one label from the others' values, defaulting to
+	// their median, which suits sensors measuring the same thing. Returns
+	// false when the others aren't enough to go on.
+	Expected func(pc calcapi.PropertyCalc, label string, others map[string]float64) (float64, bool)
+	// When the group can't vote, e.g. a pair, the sensor that jumped away
+	// from its own median over this window on its own is dropped instead.
+	// Zero skips the check.
+	Window time.Duration
+	Fused  string // Optional
+	Method FusionMethod
+}
+
+// TemporalOnly is an Expected for related sensors that can't be estimated
+// from each other, such as port and starboard flows
+func TemporalOnly(calcapi.PropertyCalc, string, map[string]float64) (float64, bool) {
+	return 0, false
+}
+
+func medianOfOthers(_ calcapi.PropertyCalc, _ string, others map[string]float64) (float64, bool) {
+	if len(others) == 0 {
+		return 0, false
+	}
+	values := make([]float64, 0, len(others))
+	for _, v := range others {
+		values = append(values, v)
+	}
+	return median(values), true
+}
+
+// How far back to look for the trim to estimate the end drafts with
+const draftTrimLookback = 6 * time.Hour
+
+// DraftGroup checks the drafts against each other. The mid drafts are
+// taken to be the mean of the forward and aft drafts, and the end drafts
+// the mean of the mid drafts less or plus half the last known trim.
+func DraftGroup(tolerance float64) SensorGroup {
+	return SensorGroup{
+		Name:      "Draft",
+		Labels:    draftLabels,
+		Tolerance: tolerance,
+		Expected:  expectedDraft,
+		Method:    FuseMean,
+	}
+}
+
+// lastKnownTrim is the trim from the end drafts of the latest earlier
+// feature where neither was dropped
+func lastKnownTrim(pc calcapi.PropertyCalc) (float64, bool) {
+	for idx := pc.FeatureIndex() - 1; withinSpan(pc, idx, draftTrimLookback); idx-- {
+		fwd, fwdOk := featureValue(pc, labels.DraftFwd, idx)
+		aft, aftOk := featureValue(pc, labels.DraftAft, idx)
+		if fwdOk && aftOk && !marked(pc, labels.DraftFwd, oddOneOutMark, idx) && !marked(pc, labels.DraftAft, oddOneOutMark, idx) {
+			return aft - fwd, true
+		}
+	}
+	return 0, false
+}
+
+func expectedDraft(pc calcapi.PropertyCalc, label string, others map[string]float64) (float64, bool) {
+	fwd, fwdOk := others[labels.DraftFwd]
+	aft, aftOk := others[labels.DraftAft]
+	var mids []float64
+	for _, mid := range []string{labels.DraftMid1, labels.DraftMid2} {
+		if v, ok := others[mid]; ok {
+			mids = append(mids, v)
+		}
+	}
+
+	switch label {
+	case labels.DraftFwd, labels.DraftAft:
+		// Estimating one end from the other would blame both equally
+		trim, ok := lastKnownTrim(pc)
+		if !ok || len(mids) == 0 {
+			return 0, false
+		}
+		if label == labels.DraftFwd {
+			trim = -trim
+		}
+		return median(mids) + trim/2, true
+	default:
+		if fwdOk && aftOk {
+			mids = append(mids, (fwd+aft)/2)
+		}
+		if len(mids) == 0 {
+			return 0, false
+		}
+		return median(mids), true
+	}
+}
+
+func (g SensorGroup) expected(pc calcapi.PropertyCalc, label string, others map[string]float64) (float64, bool) {
+	if g.Expected == nil {
+		return medianOfOthers(pc, label, others)
+	}
+	return g.Expected(pc, label, others)
+}
+
+func without(values map[string]float64, label string) map[string]float64 {
+	others := make(map[string]float64, len(values))
+	for l, v := range values {
+		if l != label {
+			others[l] = v
+		}
+	}
+	return others
+}
+
+// agree reports whether every value is estimated within tolerance from the
+// others, for at least two values
+func (g SensorGroup) agree(pc calcapi.PropertyCalc, values map[string]float64) bool {
+	checked := 0
+	for label, v := range values {
+		exp, ok := g.expected(pc, label, without(values, label))
+		if !ok {
+			continue
+		}
+		if math.Abs(v-exp) > g.Tolerance {
+			return false
+		}
+		checked++
+	}
+	return checked >= 2
+}
+
+// oddOneOut finds the sensor to drop and an estimate of what it should
+// read. consistent is false when the group disagrees with no single
+// sensor to blame.
+func (g SensorGroup) oddOneOut(pc calcapi.PropertyCalc, values map[string]float64) (label string, estimate float64, consistent bool) {
+	worst, tied := 0.0, false
+	for _, l := range g.Labels {
+		v, ok := values[l]
+		if !ok {
+			continue
+		}
+		exp, ok := g.expected(pc, l, without(values, l))
+		if !ok {
+			continue
+		}
+		switch residual := math.Abs(v - exp); {
+		case residual > worst+1e-9:
+			label, estimate, worst, tied = l, exp, residual, false
+		case residual > worst-1e-9:
+			tied = true
+		}
+	}
+	if label != "" && worst <= g.Tolerance {
+		return "", 0, true
+	}
+	// When the estimates can't single out one sensor, the order of the
+	// labels mustn't decide which is dropped
+	if label != "" && !tied && g.agree(pc, without(values, label)) {
+		return label, estimate, true
+	}
+
+	if g.Window == 0 {
+		return "", 0, label == ""
+	}
+	var jumped []string
+	var medians []float64
+	for _, l := range g.Labels {
+		v, ok := values[l]
+		if !ok {
+			continue
+		}
+		window := spanValues(pc, l, g.Window)
+		if m := median(window); len(window) >= 3 && math.Abs(v-m) > g.Tolerance {
+			jumped, medians = append(jumped, l), append(medians, m)
+		}
+	}
+	if len(jumped) == 1 {
+		return jumped[0], medians[0], true
+	}
+	return "", 0, label == "" && len(jumped) == 0
+}
+
+func fuse(values []float64, method FusionMethod) float64 {
+	if method == FuseMedian {
+		return median(values)
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	if method == FuseSum {
+		return sum
+	}
+	return sum / float64(len(values))
+}
+
+// SensorFusion drops the odd one out of a group of sensors and writes the
+// fused value of the group, using the estimate in place of the dropped
+// sensor. A sum is only written when every sensor reported, and nothing is
+// written when the group disagrees with no single sensor to blame.
+func SensorFusion(group SensorGroup, action Action) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		values := map[string]float64{}
+		for _, label := range group.Labels {
+			if v := pc.GetNullableProperty(label); v.Present() {
+				values[label] = v.Value()
+			}
+		}
+		if len(values) == 0 {
+			return
+		}
+
+		odd, estimate, consistent := group.oddOneOut(pc, values)
+		if !consistent {
+			markPoint(pc, group.Name, inconsistentMark)
+			return
+		}
+		if odd != "" {
+			log.Debugf("Dropped %s from %s on %s for ship %d", odd, group.Name, pc.Time().Format(time.RFC3339), pc.GetShip().ID)
+			applyAction(pc, odd, action, oddOneOutMark)
+			values[odd] = estimate
+		}
+
+		if group.Fused == "" || (group.Method == FuseSum && len(values) < len(group.Labels)) {
+			return
+		}
+		fused := make([]float64, 0, len(values))
+		for _, label := range group.Labels {
+			if v, ok := values[label]; ok {
+				fused = append(fused, v)
+			}
+		}
+		pc.SetProperty(group.Fused, fuse(fused, group.Method))
+	}
+}
@@ -0,0 +1,76 @@
+package cleanup
+
+import (
+	"math"
+	"testing"
+
+	"github.com/nautiluslabsco/ln/shared/constants/labels"
+)
+
+func TestSensorFusionDrafts(t *testing.T) {
+	drafts := func(fwd, aft, mid1, mid2 float64) map[string]float64 {
+		return map[string]float64{labels.DraftFwd: fwd, labels.DraftAft: aft, labels.DraftMid1: mid1, labels.DraftMid2: mid2}
+	}
+	tests := []struct {
+		name         string
+		previous     map[string]float64 // Gives the last known trim, if set
+		current      map[string]float64
+		dropped      string
+		inconsistent bool
+		fused        float64 // Zero when not written
+	}{
+		{
+			name:     "bad aft draft",
+			previous: drafts(10.5, 11.5, 11, 11),
+			current:  drafts(10, 20, 11, 11),
+			dropped:  labels.DraftAft,
+			fused:    (10 + 11.5 + 11 + 11) / 4,
+		},
+		{
+			name:     "bad forward draft",
+			previous: drafts(10.5, 11.5, 11, 11),
+			current:  drafts(2, 11.5, 11, 11),
+			dropped:  labels.DraftFwd,
+			fused:    (10.5 + 11.5 + 11 + 11) / 4,
+		},
+		{
+			name:     "bad mid draft",
+			previous: drafts(10.5, 11.5, 11, 11),
+			current:  drafts(10.5, 11.5, 15, 11),
+			dropped:  labels.DraftMid1,
+			fused:    11,
+		},
+		{
+			name:         "end drafts disagree with no trim to go on",
+			current:      drafts(10, 20, 11, 11),
+			inconsistent: true,
+		},
+		{
+			name:     "consistent",
+			previous: drafts(10.5, 11.5, 11, 11),
+			current:  drafts(10.6, 11.4, 11.1, 10.9),
+			fused:    11,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := DraftGroup(0.5)
+			group.Fused = "Mean Draft"
+			pc := newFakeCalc(tt.previous, tt.current)
+			pc.idx = 1
+			SensorFusion(group, FlagAction)(pc)
+
+			for _, label := range draftLabels {
+				if got := marked(pc, label, oddOneOutMark, 1); got != (label == tt.dropped) {
+					t.Errorf("%s dropped %v, want %v", label, got, !got)
+				}
+			}
+			if got := marked(pc, "Draft", inconsistentMark, 1); got != tt.inconsistent {
+				t.Errorf("inconsistent %v, want %v", got, tt.inconsistent)
+			}
+			if got := pc.features[1]["Mean Draft"]; math.Abs(got-tt.fused) > 1e-9 {
+				t.Errorf("fused %v, want %v", got, tt.fused)
+			}
+		})
+	}
+}
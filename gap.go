@@ -0,0 +1,48 @@
+package cleanup
+
+import (
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	"github.com/nautiluslabsco/null"
+)
+
+const interpolatedMark = "Interpolated"
+
+// nearestPosition walks from idx in steps of dir, no further than limit
+// from it, to the nearest usable fix
+func nearestPosition(pc calcapi.PropertyCalc, idx, dir int, limit time.Duration) (LatLon, int, bool) {
+	for i := idx + dir; withinSpan(pc, i, limit); i += dir {
+		if substituted(pc, positionMark, i) {
+			continue
+		}
+		if p, ok := featurePosition(pc, i); ok && validCoordinates(p) {
+			return p, i, true
+		}
+	}
+	return LatLon{}, 0, false
+}
+
+// InterpolatePositionGap fills a missing position along the great circle
+// between the fixes either side of the gap, when those fixes are no more
+// than maxGap apart. Rules removing the bad fixes need to run in an
+// earlier stage so the surrounding features are already cleaned.
+func InterpolatePositionGap(maxGap time.Duration) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		if _, ok := currentPosition(pc); ok {
+			return
+		}
+		idx := pc.FeatureIndex()
+		from, before, ok := nearestPosition(pc, idx, -1, maxGap)
+		if !ok {
+			return
+		}
+		to, after, ok := nearestPosition(pc, idx, 1, maxGap)
+		if !ok || featureTime(pc, after).Sub(featureTime(pc, before)) > maxGap {
+			return
+		}
+		p := GreatCircleInterpolate(from, to, timeFraction(pc, before, idx, after))
+		pc.SetPosition(null.FloatFrom(p.Latitude), null.FloatFrom(p.Longitude))
+		markPoint(pc, positionMark, interpolatedMark)
+	}
+}
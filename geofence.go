@@ -0,0 +1,177 @@
+package cleanup
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+)
+
+type Geofence interface {
+	Contains(p LatLon) bool
+}
+
+// BoundingBox with a MinLongitude east of its MaxLongitude spans the antimeridian
+type BoundingBox struct {
+	MinLatitude  float64
+	MaxLatitude  float64
+	MinLongitude float64
+	MaxLongitude float64
+}
+
+func (b BoundingBox) Contains(p LatLon) bool {
+	if p.Latitude < b.MinLatitude || p.Latitude > b.MaxLatitude {
+		return false
+	}
+	lon := NormalizeLongitude(p.Longitude)
+	minLon, maxLon := NormalizeLongitude(b.MinLongitude), NormalizeLongitude(b.MaxLongitude)
+	if minLon <= maxLon {
+		return lon >= minLon && lon <= maxLon
+	}
+	return lon >= minLon || lon <= maxLon
+}
+
+// Polygon is a closed ring of vertices. Longitudes are unwrapped edge by edge,
+// so rings crossing the antimeridian work without splitting them.
+type Polygon []LatLon
+
+func (poly Polygon) unwrappedLongitudes() []float64 {
+	xs := make([]float64, len(poly))
+	for i, v := range poly {
+		if i == 0 {
+			xs[i] = NormalizeLongitude(v.Longitude)
+			continue
+		}
+		xs[i] = xs[i-1] + LongitudeDelta(poly[i-1].Longitude, v.Longitude)
+	}
+	return xs
+}
+
+func (poly Polygon) Contains(p LatLon) bool {
+	if len(poly) < 3 {
+		return false
+	}
+	xs := poly.unwrappedLongitudes()
+	lon := NormalizeLongitude(p.Longitude)
+	for _, x := range []float64{lon, lon + 360, lon - 360} {
+		if poly.crossings(xs, x, p.Latitude)%2 == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func (poly Polygon) crossings(xs []float64, x, y float64) int {
+	n := 0
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		yi, yj := poly[i].Latitude, poly[j].Latitude
+		if (yi > y) != (yj > y) && x < xs[i]+(y-yi)*(xs[j]-xs[i])/(yj-yi) {
+			n++
+		}
+	}
+	return n
+}
+
+// DistanceToEdgeNM approximates the distance from p to the nearest edge,
+// using a flat projection centred on p
+func (poly Polygon) DistanceToEdgeNM(p LatLon) float64 {
+	scale := math.Cos(p.Latitude * math.Pi / 180)
+	project := func(v LatLon) (float64, float64) {
+		return LongitudeDelta(p.Longitude, v.Longitude) * scale * 60, (v.Latitude - p.Latitude) * 60
+	}
+	nearest := math.Inf(1)
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		ax, ay := project(poly[j])
+		bx, by := project(poly[i])
+		dx, dy := bx-ax, by-ay
+		t := 0.0
+		if l := dx*dx + dy*dy; l > 0 {
+			t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/l))
+		}
+		nearest = math.Min(nearest, math.Hypot(ax+t*dx, ay+t*dy))
+	}
+	return nearest
+}
+
+// Area is a polygon with optional holes, as in a GeoJSON Polygon
+type Area struct {
+	Name  string
+	Outer Polygon
+	Holes []Polygon
+}
+
+func (a Area) Contains(p LatLon) bool {
+	if !a.Outer.Contains(p) {
+		return false
+	}
+	for _, hole := range a.Holes {
+		if hole.Contains(p) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a Area) DistanceToEdgeNM(p LatLon) float64 {
+	nearest := a.Outer.DistanceToEdgeNM(p)
+	for _, hole := range a.Holes {
+		nearest = math.Min(nearest, hole.DistanceToEdgeNM(p))
+	}
+	return nearest
+}
+
+type geoJSONCollection struct {
+	Features []struct {
+		Properties struct {
+			Name string `json:"name"`
+		} `json:"properties"`
+		Geometry struct {
+			Type        string         `json:"type"`
+			Coordinates [][][2]float64 `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+// parseAreas reads the Polygon features of a GeoJSON feature collection
+func parseAreas(data []byte) []Area {
+	var collection geoJSONCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		panic(err)
+	}
+	var areas []Area
+	for _, feature := range collection.Features {
+		if feature.Geometry.Type != "Polygon" || len(feature.Geometry.Coordinates) == 0 {
+			continue
+		}
+		area := Area{Name: feature.Properties.Name}
+		for i, ring := range feature.Geometry.Coordinates {
+			poly := make(Polygon, len(ring))
+			for j, c := range ring {
+				poly[j] = LatLon{Latitude: c[1], Longitude: c[0]}
+			}
+			if i == 0 {
+				area.Outer = poly
+			} else {
+				area.Holes = append(area.Holes, poly)
+			}
+		}
+		areas = append(areas, area)
+	}
+	return areas
+}
+
+// InsideGeofence is a rule condition that holds when the point's position
+// is inside the fence
+func InsideGeofence(fence Geofence) func(calcapi.PropertyCalc) bool {
+	return func(pc calcapi.PropertyCalc) bool {
+		p, ok := currentPosition(pc)
+		return ok && fence.Contains(p)
+	}
+}
+
+func OutsideGeofence(fence Geofence) func(calcapi.PropertyCalc) bool {
+	return func(pc calcapi.PropertyCalc) bool {
+		p, ok := currentPosition(pc)
+		return ok && !fence.Contains(p)
+	}
+}
@@ -0,0 +1,63 @@
+package cleanup
+
+import (
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	"github.com/nautiluslabsco/null"
+)
+
+const (
+	substitutedMark = "Substituted"
+	positionMark    = "Position"
+)
+
+// holdable reports whether a value at idx may still be carried forward,
+// being no older than maxAge and no more than maxFills features back
+func holdable(pc calcapi.PropertyCalc, idx int, maxAge time.Duration, maxFills int) bool {
+	return pc.FeatureIndex()-idx <= maxFills && withinSpan(pc, idx, maxAge)
+}
+
+func substituted(pc calcapi.PropertyCalc, label string, idx int) bool {
+	return marked(pc, label, substitutedMark, idx)
+}
+
+// HoldLastValue fills a missing label with the last original value, as long
+// as it is no older than maxAge and no more than maxFills consecutive points
+// have been filled from it
+func HoldLastValue(label string, maxAge time.Duration, maxFills int) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		if pc.GetNullableProperty(label).Present() {
+			return
+		}
+		for idx := pc.FeatureIndex() - 1; holdable(pc, idx, maxAge, maxFills); idx-- {
+			if substituted(pc, label, idx) {
+				continue
+			}
+			if v, ok := featureValue(pc, label, idx); ok {
+				pc.SetProperty(label, v)
+				markPoint(pc, label, substitutedMark)
+				return
+			}
+		}
+	}
+}
+
+// HoldLastPosition is HoldLastValue for the position
+func HoldLastPosition(maxAge time.Duration, maxFills int) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		if _, ok := currentPosition(pc); ok {
+			return
+		}
+		for idx := pc.FeatureIndex() - 1; holdable(pc, idx, maxAge, maxFills); idx-- {
+			if substituted(pc, positionMark, idx) {
+				continue
+			}
+			if p, ok := featurePosition(pc, idx); ok && validCoordinates(p) {
+				pc.SetPosition(null.FloatFrom(p.Latitude), null.FloatFrom(p.Longitude))
+				markPoint(pc, positionMark, substitutedMark)
+				return
+			}
+		}
+	}
+}
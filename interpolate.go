@@ -0,0 +1,120 @@
+package cleanup
+
+import (
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+)
+
+type InterpolationMethod string
+
+const (
+	LinearInterpolation   InterpolationMethod = "linear"
+	NearestInterpolation  InterpolationMethod = "nearest"
+	PreviousInterpolation InterpolationMethod = "previous"
+	CubicInterpolation    InterpolationMethod = "cubic"
+)
+
+// nearestValue walks from idx in steps of dir, no further than limit from
+// it, to the nearest present value of label. It stops at either end of the
+// series.
+func nearestValue(pc calcapi.PropertyCalc, label string, idx, dir int, limit time.Duration) (float64, int, bool) {
+	for i := idx + dir; hasFeature(pc, i) && absDuration(featureTime(pc, i).Sub(featureTime(pc, idx))) <= limit; i += dir {
+		if v, ok := featureValue(pc, label, i); ok {
+			return v, i, true
+		}
+	}
+	return 0, 0, false
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+type sample struct {
+	hours float64 // relative to the point being interpolated
+	value float64
+}
+
+// lagrange evaluates the polynomial through the samples at zero
+func lagrange(samples []sample) float64 {
+	result := 0.0
+	for i, si := range samples {
+		term := si.value
+		for j, sj := range samples {
+			if i != j {
+				term *= sj.hours / (sj.hours - si.hours)
+			}
+		}
+		result += term
+	}
+	return result
+}
+
+// interpolateAt estimates label at idx from its neighbours, ignoring any
+// value idx itself has. Apart from previous, which only looks back, the
+// values either side must be no more than maxGap apart.
+func interpolateAt(pc calcapi.PropertyCalc, label string, idx int, method InterpolationMethod, maxGap time.Duration) (float64, bool) {
+	hoursTo := func(i int) float64 {
+		return hoursBetween(pc, idx, i)
+	}
+
+	before, i0, ok := nearestValue(pc, label, idx, -1, maxGap)
+	if !ok {
+		return 0, false
+	}
+	if method == PreviousInterpolation {
+		return before, -hoursTo(i0) <= maxGap.Hours()
+	}
+	after, i1, ok := nearestValue(pc, label, idx, 1, maxGap)
+	if !ok || hoursTo(i1)-hoursTo(i0) > maxGap.Hours() {
+		return 0, false
+	}
+
+	switch method {
+	case NearestInterpolation:
+		if hoursTo(i1) < -hoursTo(i0) {
+			return after, true
+		}
+		return before, true
+	case CubicInterpolation:
+		outerBefore, j0, okBefore := nearestValue(pc, label, i0, -1, maxGap)
+		outerAfter, j1, okAfter := nearestValue(pc, label, i1, 1, maxGap)
+		if okBefore && okAfter {
+			return lagrange([]sample{
+				{hoursTo(j0), outerBefore},
+				{hoursTo(i0), before},
+				{hoursTo(i1), after},
+				{hoursTo(j1), outerAfter},
+			}), true
+		}
+	}
+	return lagrange([]sample{{hoursTo(i0), before}, {hoursTo(i1), after}}), true
+}
+
+// Interpolate fills a missing label from its neighbours
+func Interpolate(label string, method InterpolationMethod, maxGap time.Duration) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		if pc.GetNullableProperty(label).Present() {
+			return
+		}
+		if v, ok := interpolateAt(pc, label, pc.FeatureIndex(), method, maxGap); ok {
+			pc.SetProperty(label, v)
+			markPoint(pc, label, interpolatedMark)
+		}
+	}
+}
+
+// ReplaceByInterpolation overwrites a bad value with one interpolated from
+// its neighbours, leaving it alone if there aren't any close enough
+func ReplaceByInterpolation(label string, method InterpolationMethod, maxGap time.Duration) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		if v, ok := interpolateAt(pc, label, pc.FeatureIndex(), method, maxGap); ok {
+			pc.SetProperty(label, v)
+			markPoint(pc, label, interpolatedMark)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package cleanup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+)
+
+func TestInterpolateAtLastFeature(t *testing.T) {
+	const label = "AE_LSFO_t_h"
+	tests := []struct {
+		method InterpolationMethod
+		filled bool // Only previous works from one side
+	}{
+		{LinearInterpolation, false},
+		{CubicInterpolation, false},
+		{NearestInterpolation, false},
+		{PreviousInterpolation, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.method), func(t *testing.T) {
+			pc := newFakeCalc(
+				map[string]float64{label: 1},
+				map[string]float64{label: 2},
+				map[string]float64{label: 3},
+			)
+			pc.idx = 2
+			ReplaceByInterpolation(label, tt.method, 2*time.Hour)(pc)
+
+			pc = newFakeCalc(
+				map[string]float64{label: 1},
+				map[string]float64{label: 2},
+				nil,
+			)
+			pc.idx = 2
+			Interpolate(label, tt.method, 2*time.Hour)(pc)
+			if v, filled := pc.features[2][label]; filled != tt.filled {
+				t.Errorf("filled %v with %v, want filled %v", filled, v, tt.filled)
+			}
+		})
+	}
+}
+
+func TestRateOfChangeLimitAtLastFeature(t *testing.T) {
+	const label = "AE_LSFO_t_h"
+	pc := newFakeCalc(
+		map[string]float64{label: 1},
+		map[string]float64{label: 1},
+		map[string]float64{label: 50},
+	)
+	pc.run(RateOfChangeLimit(label, 5, NullReplacement))
+	if v, ok := pc.features[2][label]; !ok || v != 50 {
+		t.Errorf("step at the last feature was replaced, got %v %v", v, ok)
+	}
+}
+
+func TestInterpolateGap(t *testing.T) {
+	const label = "AE_LSFO_t_h"
+	pc := newFakeCalc(
+		map[string]float64{label: 1},
+		nil,
+		map[string]float64{label: 3},
+	)
+	pc.run(Interpolate(label, LinearInterpolation, 2*time.Hour))
+	if v := pc.features[1][label]; v != 2 {
+		t.Errorf("got %v, want 2", v)
+	}
+}
+
+func TestInterpolateLinearInTime(t *testing.T) {
+	const label = "AE_LSFO_t_h"
+	pc := newFakeCalc(
+		map[string]float64{label: 0},
+		nil,
+		map[string]float64{label: 4},
+	)
+	pc.times = []time.Time{fakeStart, fakeStart.Add(time.Hour), fakeStart.Add(4 * time.Hour)}
+	pc.run(Interpolate(label, LinearInterpolation, 4*time.Hour))
+	if v := pc.features[1][label]; v != 1 {
+		t.Errorf("got %v, want 1", v)
+	}
+}
+
+// currentOnlyCalc can't say how long its series is
+type currentOnlyCalc struct {
+	calcapi.PropertyCalc
+}
+
+func TestInterpolateWithoutSeries(t *testing.T) {
+	const label = "AE_LSFO_t_h"
+	pc := newFakeCalc(
+		map[string]float64{label: 1},
+		nil,
+		map[string]float64{label: 3},
+	)
+	pc.idx = 1
+	Interpolate(label, LinearInterpolation, 2*time.Hour)(currentOnlyCalc{pc})
+	if v, filled := pc.features[1][label]; filled {
+		t.Errorf("filled with %v from features it can't know are there", v)
+	}
+}
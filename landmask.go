@@ -0,0 +1,83 @@
+package cleanup
+
+import (
+	_ "embed"
+	"math"
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	log "github.com/sirupsen/logrus"
+)
+
+// Coarse coastline outlines drawn inside the coast, with large inland and
+// enclosed waters, bays and the navigable estuaries up to the major ports
+// cut out as holes. The outlines were digitised by hand for this package
+// rather than taken from a coastline dataset, so they carry no third-party
+// licence. Edit the geojson directly to correct them, checking the ports in
+// the tests still come out at sea. Natural Earth's public domain land
+// polygons are the dataset to switch to if they need to be more accurate.
+//
+//go:embed landmask.geojson
+var landMaskData []byte
+
+var landMask = parseAreas(landMaskData)
+
+// LandMaskAccuracyNM is how far inland of the mask's outlines a real berth
+// can be. Tolerances below it will flag fixes in port.
+const LandMaskAccuracyNM = 10.0
+
+const inlandMark = "Inland"
+
+// InlandDistanceNM reports how far inside the land mask a position is
+func InlandDistanceNM(p LatLon) (float64, bool) {
+	for _, area := range landMask {
+		if area.Contains(p) {
+			return area.DistanceToEdgeNM(p), true
+		}
+	}
+	return 0, false
+}
+
+// CoastDistanceNM is how far a position is from the land mask, zero on land
+func CoastDistanceNM(p LatLon) float64 {
+	nearest := math.Inf(1)
+	for _, area := range landMask {
+		if area.Contains(p) {
+			return 0
+		}
+		nearest = math.Min(nearest, area.DistanceToEdgeNM(p))
+	}
+	return nearest
+}
+
+// OnLand holds for fixes more than toleranceNM inland. Fixes inside a port
+// area never count, since docks and river berths may be outside the mask's
+// holes.
+func OnLand(toleranceNM float64) func(calcapi.PropertyCalc) bool {
+	return func(pc calcapi.PropertyCalc) bool {
+		p, ok := currentPosition(pc)
+		if !ok {
+			return false
+		}
+		if _, inPort := InAnyPort(p); inPort {
+			return false
+		}
+		d, inland := InlandDistanceNM(p)
+		return inland && d > toleranceNM
+	}
+}
+
+// LandMaskFilter flags, or removes, fixes more than toleranceNM inland
+func LandMaskFilter(toleranceNM float64, action Action) func(calcapi.PropertyCalc) {
+	onLand := OnLand(toleranceNM)
+	return func(pc calcapi.PropertyCalc) {
+		if !onLand(pc) {
+			return
+		}
+		log.Debugf("Position on %s for ship %d is inland", pc.Time().Format(time.RFC3339), pc.GetShip().ID)
+		markPoint(pc, positionMark, inlandMark)
+		if action == NullAction {
+			RemoveBadGPS(pc)
+		}
+	}
+}
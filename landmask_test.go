@@ -0,0 +1,53 @@
+package cleanup
+
+import "testing"
+
+func TestLandMaskKeepsPorts(t *testing.T) {
+	tests := []struct {
+		name string
+		p    LatLon
+	}{
+		{"Hamburg", LatLon{Latitude: 53.53, Longitude: 9.95}},
+		{"Antwerp", LatLon{Latitude: 51.28, Longitude: 4.33}},
+		{"Bremen", LatLon{Latitude: 53.1, Longitude: 8.75}},
+		{"Rouen", LatLon{Latitude: 49.44, Longitude: 1.06}},
+		{"Bordeaux", LatLon{Latitude: 44.86, Longitude: -0.55}},
+		{"London", LatLon{Latitude: 51.45, Longitude: 0.35}},
+		{"Oslo", LatLon{Latitude: 59.9, Longitude: 10.73}},
+		{"Baton Rouge", LatLon{Latitude: 30.44, Longitude: -91.19}},
+		{"Rosario", LatLon{Latitude: -32.95, Longitude: -60.63}},
+		{"Paranagua", LatLon{Latitude: -25.5, Longitude: -48.52}},
+		{"Luanda", LatLon{Latitude: -8.8, Longitude: 13.23}},
+		{"Nanjing", LatLon{Latitude: 32.1, Longitude: 118.75}},
+		{"Surabaya", LatLon{Latitude: -7.2, Longitude: 112.73}},
+		{"Nagoya", LatLon{Latitude: 35.05, Longitude: 136.85}},
+		{"Yokohama", LatLon{Latitude: 35.45, Longitude: 139.65}},
+		{"Chiba", LatLon{Latitude: 35.57, Longitude: 140.08}},
+		{"Osaka", LatLon{Latitude: 34.65, Longitude: 135.43}},
+		{"Sakai", LatLon{Latitude: 34.58, Longitude: 135.45}},
+		{"Kobe", LatLon{Latitude: 34.68, Longitude: 135.2}},
+		{"Mizushima", LatLon{Latitude: 34.5, Longitude: 133.74}},
+		{"Hiroshima", LatLon{Latitude: 34.35, Longitude: 132.46}},
+		{"Tokuyama", LatLon{Latitude: 34.03, Longitude: 131.8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The mask itself, without the port areas OnLand skips
+			if d, inland := InlandDistanceNM(tt.p); inland && d > LandMaskAccuracyNM {
+				t.Errorf("%v is %.1f NM inland", tt.p, d)
+			}
+		})
+	}
+
+	for name, p := range map[string]LatLon{
+		"Berlin":      {Latitude: 52.52, Longitude: 13.4},
+		"Kyoto":       {Latitude: 35.01, Longitude: 135.77},
+		"Lillehammer": {Latitude: 61.12, Longitude: 10.47},
+		"Yogyakarta":  {Latitude: -7.8, Longitude: 110.37},
+	} {
+		pc := newFakeCalc(map[string]float64{latitudeLabel: p.Latitude, longitudeLabel: p.Longitude})
+		if !OnLand(LandMaskAccuracyNM)(pc) {
+			t.Errorf("%s isn't on land", name)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package cleanup
+
+import (
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc"
+	"github.com/nautiluslabsco/ln/shared/constants/units"
+)
+
+var tagMappings = concatMappings(
+	chevronGeneratorPowerMappings(207, parseTime("2020-09-30 06:00")),
+	chevronGeneratorPowerMappings(896, parseTime("2020-09-13 16:00")),
+	chevronGeneratorPowerMappings(971, parseTime("2020-09-27 00:00")),
+	hoppeModeSwitchMappings(calc.DbcSincerePisces, parseTime("2020-11-25 00:00")),
+	[]TagMapping{
+		{
+			Comment:  "Hunter Freya - Use Deprecated Fuel Tag Prior to New Tag Addition",
+			Issue:    "ENG-1007",
+			ShipID:   calc.HunterFreya,
+			Start:    time.Time{}, // Unbounded
+			End:      parseTime("2021-05-27 00:00"),
+			Stage:    PreVesselAnatomyStage,
+			Source:   "Engines_Main_1_Fuel_Oil_System_FO_Inlet_Flow_Mass",
+			Target:   "Engines_Main_FO_Flow",
+			Conflict: ReplaceTarget,
+		},
+		{
+			Comment:  "Hunter Frigg - Use Deprecated Fuel Tag Prior to New Tag Addition",
+			Issue:    "ENG-1007",
+			ShipID:   calc.HunterFrigg,
+			Start:    time.Time{}, // Unbounded
+			End:      parseTime("2021-05-27 00:00"),
+			Stage:    PreVesselAnatomyStage,
+			Source:   "Engines_Main_1_Fuel_Oil_System_FO_Inlet_Flow_Mass",
+			Target:   "Engines_Main_FO_Flow",
+			Conflict: ReplaceTarget,
+		},
+	},
+)
+
+func concatMappings(groups ...[]TagMapping) []TagMapping {
+	var mappings []TagMapping
+	for _, group := range groups {
+		mappings = append(mappings, group...)
+	}
+	return mappings
+}
+
+func chevronGeneratorPowerMappings(shipID int64, end time.Time) []TagMapping {
+	return []TagMapping{{
+		Comment:   "Generator Power tags were changed",
+		Issue:     "DPI-922",
+		ShipID:    shipID,
+		Start:     parseTime("2020-01-01 00:00"),
+		End:       end,
+		Stage:     PostVesselAnatomyStage,
+		Source:    "M/G {n} Power",
+		Target:    "Generator {n} Power",
+		Unit:      &units.KiloWatts,
+		Conflict:  PreferSource,
+		Component: Generators,
+	}}
+}
+
+// Sets the shared Hoppe mode switch tags from the DBC exclusive mode switch tags
+func hoppeModeSwitchMappings(shipID int64, end time.Time) []TagMapping {
+	var mappings []TagMapping
+	for _, fuel := range hoppeFuels {
+		mappings = append(mappings, TagMapping{
+			Comment:  "Diamond Bulk Sincere Pisces - Alias alternative mode switch tags",
+			Issue:    "ENG-476",
+			ShipID:   shipID,
+			Start:    time.Time{}, // Unbounded
+			End:      end,
+			Stage:    PreVesselAnatomyStage,
+			Source:   "Main Engine using " + fuel,
+			Target:   "Main Engine on " + fuel,
+			Conflict: ReplaceTarget,
+		})
+	}
+	return mappings
+}
@@ -0,0 +1,47 @@
+package cleanup
+
+import (
+	"strings"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	"github.com/nautiluslabsco/ln/shared/models"
+)
+
+// Prefix for the labels the cleanup funcs use to record what they did to a point
+const cleanupLabelPrefix = "(Cleanup) "
+
+func cleanupLabel(parts ...string) string {
+	return cleanupLabelPrefix + strings.Join(parts, " ")
+}
+
+func markPoint(pc calcapi.PropertyCalc, parts ...string) {
+	pc.SetProperty(cleanupLabel(parts...), 1)
+}
+
+// Records the value a check replaced, so checks on later features can
+// still see what the sensor reported
+const originalMark = "Original"
+
+func recordOriginal(pc calcapi.PropertyCalc, label string) {
+	if v := pc.GetNullableProperty(label); v.Present() {
+		pc.SetProperty(cleanupLabel(label, originalMark), v.Value())
+	}
+}
+
+// Action is what a check does with a value it finds implausible
+type Action string
+
+const (
+	FlagAction Action = "flag"
+	NullAction Action = "null"
+)
+
+// applyAction marks the label with why it failed a check, and nulls it
+// as well unless the action only flags
+func applyAction(pc calcapi.PropertyCalc, label string, action Action, mark string) {
+	markPoint(pc, label, mark)
+	if action == NullAction {
+		recordOriginal(pc, label)
+		pc.SetNullableProperty(label, models.NullValue())
+	}
+}
@@ -0,0 +1,122 @@
+package cleanup
+
+import (
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	"github.com/nautiluslabsco/ln/shared/constants/labels"
+)
+
+type OperationalMode string
+
+const (
+	AtSeaMode       OperationalMode = "at-sea"
+	ManoeuvringMode OperationalMode = "manoeuvring"
+	InPortMode      OperationalMode = "in-port"
+	DriftingMode    OperationalMode = "drifting"
+	UnknownMode     OperationalMode = "unknown"
+)
+
+type ModeThresholds struct {
+	IdleShaftSpeed float64 // rpm below which the shaft is considered stopped
+	AtSeaSOG       float64 // knots at or above which the ship is on passage
+	StoppedSOG     float64 // knots below which the ship is stationary
+	// How long the ship must be stationary with the shaft stopped to be in
+	// port rather than drifting
+	InPortAfter time.Duration
+	// How near the land mask a stationary ship must be to be in port, so a
+	// ship drifting waiting for orders at sea isn't. Zero skips the check.
+	PortDistanceNM float64
+}
+
+var defaultModeThresholds = ModeThresholds{
+	IdleShaftSpeed: 5,
+	AtSeaSOG:       5,
+	StoppedSOG:     0.5,
+	InPortAfter:    2 * time.Hour,
+	PortDistanceNM: 20,
+}
+
+// Ships whose thresholds differ from the defaults, e.g. slow steamers
+var modeThresholds = map[int64]ModeThresholds{}
+
+func modeThresholdsFor(shipID int64) ModeThresholds {
+	if t, ok := modeThresholds[shipID]; ok {
+		return t
+	}
+	return defaultModeThresholds
+}
+
+// stoppedAt reports whether the ship is stationary at idx with the shaft
+// stopped, or not reporting a shaft speed
+func (t ModeThresholds) stoppedAt(pc calcapi.PropertyCalc, idx int) bool {
+	sog, sogOk := featureValue(pc, labels.SpeedOverGround, idx)
+	rpm, rpmOk := featureValue(pc, labels.ShaftSpeed, idx)
+	return sogOk && sog < t.StoppedSOG && (!rpmOk || rpm < t.IdleShaftSpeed)
+}
+
+// alongside reports whether the ship at idx has been stopped for
+// InPortAfter near enough to land to be alongside or at anchor
+func (t ModeThresholds) alongside(pc calcapi.PropertyCalc, idx int) bool {
+	if !t.stoppedAt(pc, idx) {
+		return false
+	}
+	if t.PortDistanceNM > 0 {
+		p, ok := featurePosition(pc, idx)
+		if !ok || !validCoordinates(p) || CoastDistanceNM(p) > t.PortDistanceNM {
+			return false
+		}
+	}
+	return sustainedAt(pc, idx, t.InPortAfter, func(i int) bool { return t.stoppedAt(pc, i) })
+}
+
+func operationalModeAt(pc calcapi.PropertyCalc, idx int) OperationalMode {
+	t := modeThresholdsFor(pc.GetShip().ID)
+	sog, sogOk := featureValue(pc, labels.SpeedOverGround, idx)
+	rpm, rpmOk := featureValue(pc, labels.ShaftSpeed, idx)
+	shaftTurning := rpmOk && rpm >= t.IdleShaftSpeed
+
+	if sogOk && sog >= t.AtSeaSOG {
+		return AtSeaMode
+	}
+	if shaftTurning {
+		if !sogOk {
+			return UnknownMode
+		}
+		return ManoeuvringMode
+	}
+	if p, ok := featurePosition(pc, idx); ok {
+		if _, inPort := InAnyPort(p); inPort {
+			return InPortMode
+		}
+	}
+	if !sogOk {
+		return UnknownMode
+	}
+	if t.alongside(pc, idx) {
+		return InPortMode
+	}
+	return DriftingMode
+}
+
+// OperationalModeOf derives the operating state of the current point from
+// its shaft speed, SOG and position. A ship is in port when it has been
+// stationary with the shaft stopped for a while near land, or anywhere in
+// one of the areas in ports.geojson, and drifting when it is stopped
+// otherwise.
+func OperationalModeOf(pc calcapi.PropertyCalc) OperationalMode {
+	return operationalModeAt(pc, pc.FeatureIndex())
+}
+
+// InMode is a rule condition that holds in any of the given modes
+func InMode(modes ...OperationalMode) func(calcapi.PropertyCalc) bool {
+	return func(pc calcapi.PropertyCalc) bool {
+		current := OperationalModeOf(pc)
+		for _, mode := range modes {
+			if mode == current {
+				return true
+			}
+		}
+		return false
+	}
+}
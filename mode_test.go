@@ -0,0 +1,44 @@
+package cleanup
+
+import (
+	"testing"
+
+	"github.com/nautiluslabsco/ln/shared/constants/labels"
+)
+
+func TestOperationalModeAlongside(t *testing.T) {
+	// Stopped off Kobe, which isn't one of the listed port areas, and in
+	// the middle of the Pacific
+	tests := []struct {
+		name  string
+		p     LatLon
+		hours int // stopped for
+		want  OperationalMode
+	}{
+		{"alongside", LatLon{Latitude: 34.68, Longitude: 135.2}, 4, InPortMode},
+		{"just stopped", LatLon{Latitude: 34.68, Longitude: 135.2}, 1, DriftingMode},
+		{"at sea", LatLon{Latitude: 20, Longitude: -150}, 4, DriftingMode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var features []map[string]float64
+			for i := 0; i <= tt.hours; i++ {
+				sog := 0.1
+				if i == 0 {
+					sog = 12
+				}
+				features = append(features, map[string]float64{
+					latitudeLabel:          tt.p.Latitude,
+					longitudeLabel:         tt.p.Longitude,
+					labels.SpeedOverGround: sog,
+					labels.ShaftSpeed:      0,
+				})
+			}
+			pc := newFakeCalc(features...)
+			pc.idx = tt.hours
+			if got := OperationalModeOf(pc); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
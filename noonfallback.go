@@ -0,0 +1,125 @@
+package cleanup
+
+import (
+	"math"
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	"github.com/nautiluslabsco/ln/shared/constants/labels"
+	"github.com/nautiluslabsco/null"
+)
+
+const noonFallbackMark = "Noon Fallback"
+
+// Longest gap between noon reports that is treated as one noon period
+const maxNoonPeriod = 36 * time.Hour
+
+// NoonFamily groups sensor labels by how their noon report equivalents
+// relate to hourly values. Totals cover the whole noon period, so whatever
+// the sensor didn't report of them is spread over the points missing a
+// value, readings are interpolated between reports, and the position is
+// interpolated along the great circle.
+type NoonFamily struct {
+	Totals   []string
+	Readings []string
+	Position bool
+}
+
+var (
+	FuelConsumptionFamily = NoonFamily{
+		Totals: []string{labels.MainEngineFuelConsumption, labels.GeneratorFuelConsumption, "Total Fuel Consumption"},
+	}
+	PositionFamily = NoonFamily{Position: true}
+)
+
+// noonPeriod finds the reports either side of the current point. The report
+// at or after it is the one covering the period the point falls in.
+func noonPeriod(pc calcapi.PropertyCalc, noonLabel string) (prev, next int, ok bool) {
+	idx := pc.FeatureIndex()
+	if _, present := featureValue(pc, noonLabel, idx); present {
+		next = idx
+	} else if _, next, ok = nearestValue(pc, noonLabel, idx, 1, maxNoonPeriod); !ok {
+		return 0, 0, false
+	}
+	if _, prev, ok = nearestValue(pc, noonLabel, next, -1, maxNoonPeriod); !ok {
+		return 0, 0, false
+	}
+	return prev, next, true
+}
+
+// noonTotalFallback takes the sensor values already in the noon period off
+// the noon report total and spreads the rest over the points missing a
+// value, each getting a share for the time since the point before it.
+// Points filled by earlier passes count as missing.
+func noonTotalFallback(pc calcapi.PropertyCalc, label string) {
+	noonLabel := labels.Noon(label)
+	prev, next, ok := noonPeriod(pc, noonLabel)
+	if !ok {
+		return
+	}
+	total, _ := featureValue(pc, noonLabel, next)
+	var reported, missingHours float64
+	for i := prev + 1; i <= next; i++ {
+		if v, ok := featureValue(pc, label, i); ok && !marked(pc, label, noonFallbackMark, i) {
+			reported += v
+		} else {
+			missingHours += hoursBetween(pc, i-1, i)
+		}
+	}
+	idx := pc.FeatureIndex()
+	pc.SetProperty(label, math.Max(total-reported, 0)*hoursBetween(pc, idx-1, idx)/missingHours)
+	markPoint(pc, label, noonFallbackMark)
+}
+
+func noonReadingFallback(pc calcapi.PropertyCalc, label string) {
+	noonLabel := labels.Noon(label)
+	prev, next, ok := noonPeriod(pc, noonLabel)
+	if !ok {
+		return
+	}
+	from, _ := featureValue(pc, noonLabel, prev)
+	to, _ := featureValue(pc, noonLabel, next)
+	f := timeFraction(pc, prev, pc.FeatureIndex(), next)
+	pc.SetProperty(label, from+(to-from)*f)
+	markPoint(pc, label, noonFallbackMark)
+}
+
+func noonPositionFallback(pc calcapi.PropertyCalc) {
+	prev, next, ok := noonPeriod(pc, labels.Noon(latitudeLabel))
+	if !ok {
+		return
+	}
+	noonPosition := func(idx int) (LatLon, bool) {
+		lat, latOk := featureValue(pc, labels.Noon(latitudeLabel), idx)
+		lon, lonOk := featureValue(pc, labels.Noon(longitudeLabel), idx)
+		return LatLon{Latitude: lat, Longitude: lon}, latOk && lonOk
+	}
+	from, fromOk := noonPosition(prev)
+	to, toOk := noonPosition(next)
+	if !fromOk || !toOk {
+		return
+	}
+	p := GreatCircleInterpolate(from, to, timeFraction(pc, prev, pc.FeatureIndex(), next))
+	pc.SetPosition(null.FloatFrom(p.Latitude), null.FloatFrom(p.Longitude))
+	markPoint(pc, positionMark, noonFallbackMark)
+}
+
+// NoonFallback fills missing sensor values in a family from the matching
+// noon report labels
+func NoonFallback(family NoonFamily) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		for _, label := range family.Totals {
+			if pc.GetNullableProperty(label).Absent() {
+				noonTotalFallback(pc, label)
+			}
+		}
+		for _, label := range family.Readings {
+			if pc.GetNullableProperty(label).Absent() {
+				noonReadingFallback(pc, label)
+			}
+		}
+		if _, ok := currentPosition(pc); family.Position && !ok {
+			noonPositionFallback(pc)
+		}
+	}
+}
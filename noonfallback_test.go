@@ -0,0 +1,43 @@
+package cleanup
+
+import (
+	"math"
+	"testing"
+
+	"github.com/nautiluslabsco/ln/shared/constants/labels"
+)
+
+func TestNoonFallbackPartlyCoveredPeriod(t *testing.T) {
+	label := labels.MainEngineFuelConsumption
+	tests := []struct {
+		name   string
+		sensor float64 // t/h reported over the first 12 h
+		want   float64 // t/h filled in over the last 12 h
+	}{
+		{"no sensor", 0, 1},
+		{"half reported", 1, 1},
+		{"all reported", 2, 0},
+		{"more than the report", 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			features := []map[string]float64{{labels.Noon(label): 30}}
+			for i := 1; i <= 24; i++ {
+				f := map[string]float64{}
+				if i <= 12 && tt.sensor > 0 {
+					f[label] = tt.sensor
+				}
+				features = append(features, f)
+			}
+			features[24][labels.Noon(label)] = 24
+			pc := newFakeCalc(features...)
+			pc.run(NoonFallback(FuelConsumptionFamily))
+
+			for i := 13; i <= 24; i++ {
+				if got := pc.features[i][label]; math.Abs(got-tt.want) > 1e-9 {
+					t.Errorf("feature %d got %v, want %v", i, got, tt.want)
+				}
+			}
+		})
+	}
+}
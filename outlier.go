@@ -0,0 +1,148 @@
+package cleanup
+
+import (
+	"math"
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	"github.com/nautiluslabsco/ln/shared/models"
+)
+
+const outlierMark = "Outlier"
+
+// Scales the MAD to estimate the standard deviation of normal data
+const madToSigma = 1.4826
+
+// Replacement is what an outlier is replaced with
+type Replacement string
+
+const (
+	NullReplacement        Replacement = "null"
+	InterpolateReplacement Replacement = "interpolate"
+	MedianReplacement      Replacement = "median"
+)
+
+// windowValues are the present values of label within halfWindow features
+// of the current one, including it
+func windowValues(pc calcapi.PropertyCalc, label string, halfWindow int) []float64 {
+	var values []float64
+	for i := pc.FeatureIndex() - halfWindow; i <= pc.FeatureIndex()+halfWindow; i++ {
+		if v, ok := featureValue(pc, label, i); ok {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// spanValues are the present values of label within d either side of the
+// current feature, including it
+func spanValues(pc calcapi.PropertyCalc, label string, d time.Duration) []float64 {
+	var values []float64
+	first := pc.FeatureIndex()
+	for withinSpan(pc, first-1, d) {
+		first--
+	}
+	for i := first; withinSpan(pc, i, d); i++ {
+		if v, ok := featureValue(pc, label, i); ok {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// windowSpan is the time from the first to the last feature within
+// halfWindow features of the current one
+func windowSpan(pc calcapi.PropertyCalc, halfWindow int) time.Duration {
+	first, last := pc.FeatureIndex(), pc.FeatureIndex()
+	for first > pc.FeatureIndex()-halfWindow && hasFeature(pc, first-1) {
+		first--
+	}
+	for last < pc.FeatureIndex()+halfWindow && hasFeature(pc, last+1) {
+		last++
+	}
+	return featureTime(pc, last).Sub(featureTime(pc, first))
+}
+
+func replaceOutlier(pc calcapi.PropertyCalc, label string, replacement Replacement, halfWindow int) {
+	markPoint(pc, label, outlierMark)
+	switch replacement {
+	case MedianReplacement:
+		pc.SetProperty(label, median(windowValues(pc, label, halfWindow)))
+		return
+	case InterpolateReplacement:
+		if v, ok := interpolateAt(pc, label, pc.FeatureIndex(), LinearInterpolation, windowSpan(pc, halfWindow)); ok {
+			pc.SetProperty(label, v)
+			return
+		}
+	}
+	pc.SetNullableProperty(label, models.NullValue())
+}
+
+// medianFilter replaces values further from the window's median than the
+// threshold for its MAD, or minDeviation when that's larger. When most of
+// the window holds the same value the MAD is zero, so without the floor
+// quantised or set-point sensors would have every small change replaced.
+func medianFilter(label string, halfWindow int, threshold func(mad float64) float64, minDeviation float64, replacement Replacement) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		v := pc.GetNullableProperty(label)
+		if v.Absent() {
+			return
+		}
+		values := windowValues(pc, label, halfWindow)
+		if len(values) < 3 {
+			return
+		}
+		m := median(values)
+		if math.Abs(v.Value()-m) > math.Max(threshold(medianAbsoluteDeviation(values, m)), minDeviation) {
+			replaceOutlier(pc, label, replacement, halfWindow)
+		}
+	}
+}
+
+// HampelFilter replaces values more than nSigmas standard deviations, as
+// estimated from the MAD, from the median of the surrounding window, and at
+// least minDeviation in the label's units
+func HampelFilter(label string, halfWindow int, nSigmas, minDeviation float64, replacement Replacement) func(calcapi.PropertyCalc) {
+	return medianFilter(label, halfWindow, func(mad float64) float64 {
+		return nSigmas * madToSigma * mad
+	}, minDeviation, replacement)
+}
+
+// RollingMedianFilter replaces values more than madThreshold MADs from the
+// median of the surrounding window, and at least minDeviation in the
+// label's units
+func RollingMedianFilter(label string, halfWindow int, madThreshold, minDeviation float64, replacement Replacement) func(calcapi.PropertyCalc) {
+	return medianFilter(label, halfWindow, func(mad float64) float64 {
+		return madThreshold * mad
+	}, minDeviation, replacement)
+}
+
+// RateOfChangeLimit replaces values that jump from both the previous and
+// next values faster than maxPerHour, so a step change isn't mistaken for
+// a spike. Without a value either side it can't tell them apart, so the
+// value is kept.
+func RateOfChangeLimit(label string, maxPerHour float64, replacement Replacement) func(calcapi.PropertyCalc) {
+	const halfWindow = 1
+	return func(pc calcapi.PropertyCalc) {
+		v := pc.GetNullableProperty(label)
+		if v.Absent() {
+			return
+		}
+		withinLimit := func(dir int) (bool, bool) {
+			i := pc.FeatureIndex() + dir
+			neighbour, ok := featureValue(pc, label, i)
+			if !ok {
+				return false, false
+			}
+			hours := math.Abs(hoursBetween(pc, pc.FeatureIndex(), i))
+			return math.Abs(v.Value()-neighbour)/hours <= maxPerHour, true
+		}
+		if ok, hasPrev := withinLimit(-1); ok || !hasPrev {
+			return
+		}
+		if ok, hasNext := withinLimit(1); ok || !hasNext {
+			return
+		}
+		replaceOutlier(pc, label, replacement, halfWindow)
+	}
+}
@@ -0,0 +1,26 @@
+package cleanup
+
+import "testing"
+
+func TestHampelFilterFlatWindow(t *testing.T) {
+	const label = "Shaft Speed"
+	tests := []struct {
+		value    float64
+		replaced bool
+	}{
+		{10.1, false},
+		{50, true},
+	}
+	for _, tt := range tests {
+		pc := newFakeCalc(
+			map[string]float64{label: 10}, map[string]float64{label: 10}, map[string]float64{label: 10},
+			map[string]float64{label: tt.value},
+			map[string]float64{label: 10}, map[string]float64{label: 10}, map[string]float64{label: 10},
+		)
+		pc.idx = 3
+		HampelFilter(label, 3, 3, 0.5, NullReplacement)(pc)
+		if _, present := pc.features[3][label]; present == tt.replaced {
+			t.Errorf("%v replaced %v, want %v", tt.value, !present, tt.replaced)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package cleanup
+
+import (
+	_ "embed"
+	"fmt"
+)
+
+// Rough outlines of the port areas, including the anchorages
+//
+//go:embed ports.geojson
+var portAreaData []byte
+
+var portAreas = func() map[string]Area {
+	areas := map[string]Area{}
+	for _, area := range parseAreas(portAreaData) {
+		areas[area.Name] = area
+	}
+	return areas
+}()
+
+// PortArea looks up a named port area, panicking like parseTime does
+// so a typo in a rule fails at startup
+func PortArea(name string) Geofence {
+	area, ok := portAreas[name]
+	if !ok {
+		panic(fmt.Sprintf("Unknown port area %q", name))
+	}
+	return area
+}
+
+// InAnyPort reports the port area containing p, if any
+func InAnyPort(p LatLon) (string, bool) {
+	for name, area := range portAreas {
+		if area.Contains(p) {
+			return name, true
+		}
+	}
+	return "", false
+}
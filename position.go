@@ -0,0 +1,169 @@
+package cleanup
+
+import (
+	"math"
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	"github.com/nautiluslabsco/ln/shared/constants/labels"
+	"github.com/nautiluslabsco/null"
+	log "github.com/sirupsen/logrus"
+)
+
+const earthRadiusNM = 3440.065
+
+// How far back to look for a noon report position when inferring signs
+const noonPositionLookback = 36 * time.Hour
+
+type LatLon struct {
+	Latitude  float64
+	Longitude float64
+}
+
+func (p LatLon) radians() (float64, float64) {
+	return p.Latitude * math.Pi / 180, p.Longitude * math.Pi / 180
+}
+
+// Normalized wraps the longitude into (-180, 180]
+func (p LatLon) Normalized() LatLon {
+	return LatLon{Latitude: p.Latitude, Longitude: NormalizeLongitude(p.Longitude)}
+}
+
+// NormalizeLongitude wraps a longitude into (-180, 180]
+func NormalizeLongitude(lon float64) float64 {
+	lon = math.Mod(lon, 360)
+	if lon > 180 {
+		lon -= 360
+	} else if lon <= -180 {
+		lon += 360
+	}
+	return lon
+}
+
+// LongitudeDelta is the signed shortest angular difference from one
+// longitude to another, so 179 to -179 is +2 rather than -358
+func LongitudeDelta(from, to float64) float64 {
+	return NormalizeLongitude(to - from)
+}
+
+// SamePosition compares positions by distance, which stays correct when
+// the two sit either side of the antimeridian
+func SamePosition(a, b LatLon, toleranceNM float64) bool {
+	return DistanceNM(a, b) <= toleranceNM
+}
+
+func (p LatLon) vector() (float64, float64, float64) {
+	lat, lon := p.radians()
+	return math.Cos(lat) * math.Cos(lon), math.Cos(lat) * math.Sin(lon), math.Sin(lat)
+}
+
+// GreatCircleInterpolate returns the point a fraction f of the way from a
+// to b along the great circle between them
+func GreatCircleInterpolate(a, b LatLon, f float64) LatLon {
+	d := DistanceNM(a, b) / earthRadiusNM
+	if d < 1e-12 {
+		return a.Normalized()
+	}
+	ax, ay, az := a.vector()
+	bx, by, bz := b.vector()
+	wa := math.Sin((1-f)*d) / math.Sin(d)
+	wb := math.Sin(f*d) / math.Sin(d)
+	x, y, z := wa*ax+wb*bx, wa*ay+wb*by, wa*az+wb*bz
+	return LatLon{
+		Latitude:  math.Atan2(z, math.Hypot(x, y)) * 180 / math.Pi,
+		Longitude: NormalizeLongitude(math.Atan2(y, x) * 180 / math.Pi),
+	}
+}
+
+// DistanceNM is the haversine distance between two positions in nautical miles
+func DistanceNM(a, b LatLon) float64 {
+	lat1, lon1 := a.radians()
+	lat2, lon2 := b.radians()
+	sinLat := math.Sin((lat2 - lat1) / 2)
+	sinLon := math.Sin((lon2 - lon1) / 2)
+	h := sinLat*sinLat + math.Cos(lat1)*math.Cos(lat2)*sinLon*sinLon
+	return 2 * earthRadiusNM * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// All hemisphere combinations for the magnitude of a position
+func signCandidates(p LatLon) []LatLon {
+	lat, lon := math.Abs(p.Latitude), math.Abs(p.Longitude)
+	return []LatLon{
+		{Latitude: lat, Longitude: lon},
+		{Latitude: -lat, Longitude: lon},
+		{Latitude: lat, Longitude: -lon},
+		{Latitude: -lat, Longitude: -lon},
+	}
+}
+
+func lastNoonPosition(pc calcapi.PropertyCalc, lookback time.Duration) (LatLon, bool) {
+	for idx := pc.FeatureIndex(); withinSpan(pc, idx, lookback); idx-- {
+		lat, latOk := featureValue(pc, labels.Noon(latitudeLabel), idx)
+		lon, lonOk := featureValue(pc, labels.Noon(longitudeLabel), idx)
+		if latOk && lonOk {
+			return LatLon{Latitude: lat, Longitude: lon}, true
+		}
+	}
+	return LatLon{}, false
+}
+
+// Sign candidates scoring within this of the best against the adjacent
+// fixes are a tie, left to the last noon report to decide
+const signTieNM = 1.0
+
+// InferLatLonSign picks the hemisphere combination for the current position
+// that is closest to the adjacent fixes. The next fix may be unsigned as
+// well, so it's compared in whichever hemisphere is nearest. The last noon
+// report can be a day and a half old, on the other side of an equator or
+// prime meridian crossing, so it only breaks ties or stands in when there
+// are no adjacent fixes.
+func InferLatLonSign(pc calcapi.PropertyCalc) {
+	pos, ok := currentPosition(pc)
+	if !ok {
+		return
+	}
+	pos = pos.Normalized()
+
+	prev := pc.PreviousPosition()
+	next, hasNext := featurePosition(pc, pc.FeatureIndex()+1)
+	noon, hasNoon := lastNoonPosition(pc, noonPositionLookback)
+	if prev == nil && !hasNext && !hasNoon {
+		return
+	}
+
+	candidates := signCandidates(pos)
+	adjacent := make([]float64, len(candidates))
+	bestAdjacent := math.Inf(1)
+	for i, candidate := range candidates {
+		if prev != nil {
+			adjacent[i] += DistanceNM(candidate, LatLon{Latitude: prev.Latitude, Longitude: prev.Longitude}.Normalized())
+		}
+		if hasNext {
+			nearest := math.Inf(1)
+			for _, n := range signCandidates(next) {
+				nearest = math.Min(nearest, DistanceNM(candidate, n))
+			}
+			adjacent[i] += nearest
+		}
+		bestAdjacent = math.Min(bestAdjacent, adjacent[i])
+	}
+
+	best, bestNoon := pos, math.Inf(1)
+	for i, candidate := range candidates {
+		if adjacent[i] > bestAdjacent+signTieNM {
+			continue
+		}
+		d := 0.0
+		if hasNoon {
+			d = DistanceNM(candidate, noon)
+		}
+		if d < bestNoon || d == bestNoon && candidate == pos {
+			best, bestNoon = candidate, d
+		}
+	}
+
+	if best != pos {
+		log.Debugf("Inferred position sign on %s for ship %d: %v -> %v", pc.Time().Format(time.RFC3339), pc.GetShip().ID, pos, best)
+		pc.SetPosition(null.FloatFrom(best.Latitude), null.FloatFrom(best.Longitude))
+	}
+}
@@ -0,0 +1,75 @@
+package cleanup
+
+import (
+	"math"
+	"testing"
+
+	"github.com/nautiluslabsco/ln/shared/constants/labels"
+)
+
+func TestInferLatLonSignCrossing(t *testing.T) {
+	tests := []struct {
+		name   string
+		prev   LatLon
+		noon   LatLon
+		sensor LatLon
+		want   LatLon
+	}{
+		{
+			name:   "equator northbound",
+			prev:   LatLon{Latitude: 0.3, Longitude: -30},
+			noon:   LatLon{Latitude: -2, Longitude: -30},
+			sensor: LatLon{Latitude: 0.5, Longitude: 30},
+			want:   LatLon{Latitude: 0.5, Longitude: -30},
+		},
+		{
+			name:   "equator southbound",
+			prev:   LatLon{Latitude: -0.3, Longitude: 80},
+			noon:   LatLon{Latitude: 2, Longitude: 80},
+			sensor: LatLon{Latitude: 0.5, Longitude: 80},
+			want:   LatLon{Latitude: -0.5, Longitude: 80},
+		},
+		{
+			name:   "prime meridian eastbound",
+			prev:   LatLon{Latitude: 50, Longitude: 0.3},
+			noon:   LatLon{Latitude: 50, Longitude: -2},
+			sensor: LatLon{Latitude: 50, Longitude: 0.5},
+			want:   LatLon{Latitude: 50, Longitude: 0.5},
+		},
+		{
+			name:   "prime meridian westbound",
+			prev:   LatLon{Latitude: 50, Longitude: -0.3},
+			noon:   LatLon{Latitude: 50, Longitude: 2},
+			sensor: LatLon{Latitude: 50, Longitude: 0.5},
+			want:   LatLon{Latitude: 50, Longitude: -0.5},
+		},
+		{
+			name:   "noon breaks a tie on the equator",
+			prev:   LatLon{Latitude: 0, Longitude: 30},
+			noon:   LatLon{Latitude: -2, Longitude: 30},
+			sensor: LatLon{Latitude: 0.01, Longitude: 30},
+			want:   LatLon{Latitude: -0.01, Longitude: 30},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pc := newFakeCalc(
+				map[string]float64{
+					latitudeLabel:               tt.prev.Latitude,
+					longitudeLabel:              tt.prev.Longitude,
+					labels.Noon(latitudeLabel):  tt.noon.Latitude,
+					labels.Noon(longitudeLabel): tt.noon.Longitude,
+				},
+				map[string]float64{
+					latitudeLabel:  math.Abs(tt.sensor.Latitude),
+					longitudeLabel: math.Abs(tt.sensor.Longitude),
+				},
+			)
+			pc.idx = 1
+			InferLatLonSign(pc)
+			if got, _ := currentPosition(pc); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
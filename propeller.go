@@ -0,0 +1,110 @@
+package cleanup
+
+import (
+	"math"
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	"github.com/nautiluslabsco/ln/shared/constants/labels"
+)
+
+const (
+	offPropellerLawMark = "Off Propeller Law"
+	aboveCeilingMark    = "Above Ceiling"
+	flatLinedMark       = "Flat Lined"
+)
+
+// PropellerLaw configures the shaft power / shaft speed consistency check.
+// Power follows k·rpm^n, with k and n fitted from the clean history.
+type PropellerLaw struct {
+	History       time.Duration
+	MinPoints     int
+	Tolerance     float64       // Allowed relative deviation from the fitted power
+	MaxPower      float64       // kW, no ceiling when zero
+	MaxShaftSpeed float64       // rpm, no ceiling when zero
+	FlatLine      time.Duration // Unchanged non-zero values for this long are flagged, off when zero
+}
+
+// propellerLawMarked reports whether any of the check's marks is on the
+// shaft power or speed at idx
+func propellerLawMarked(pc calcapi.PropertyCalc, idx int) bool {
+	for _, label := range []string{labels.ShaftPower, labels.ShaftSpeed} {
+		for _, mark := range []string{offPropellerLawMark, aboveCeilingMark, flatLinedMark} {
+			if marked(pc, label, mark, idx) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fit regresses ln(power) on ln(rpm) over the features before idx that the
+// check hasn't marked
+func (law PropellerLaw) fit(pc calcapi.PropertyCalc, idx int) (k, n float64, ok bool) {
+	idle := modeThresholdsFor(pc.GetShip().ID).IdleShaftSpeed
+	var sx, sy, sxx, sxy, count float64
+	for i := idx - 1; withinSpan(pc, i, law.History); i-- {
+		if propellerLawMarked(pc, i) {
+			continue
+		}
+		power, powerOk := featureValue(pc, labels.ShaftPower, i)
+		rpm, rpmOk := featureValue(pc, labels.ShaftSpeed, i)
+		if !powerOk || !rpmOk || power <= 0 || rpm <= idle {
+			continue
+		}
+		x, y := math.Log(rpm), math.Log(power)
+		sx, sy, sxx, sxy, count = sx+x, sy+y, sxx+x*x, sxy+x*y, count+1
+	}
+	if count < float64(law.MinPoints) || count < 2 {
+		return 0, 0, false
+	}
+	denom := count*sxx - sx*sx
+	if denom < 1e-9 {
+		return 0, 0, false
+	}
+	n = (count*sxy - sx*sy) / denom
+	return math.Exp((sy - n*sx) / count), n, true
+}
+
+func PropellerLawCheck(law PropellerLaw, action Action) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		power := pc.GetNullableProperty(labels.ShaftPower)
+		rpm := pc.GetNullableProperty(labels.ShaftSpeed)
+
+		if law.FlatLine > 0 {
+			flatLined := false
+			for _, label := range []string{labels.ShaftPower, labels.ShaftSpeed} {
+				v := pc.GetNullableProperty(label)
+				if v.Present() && v.Value() != 0 && (StuckSensor{Label: label, MinRun: law.FlatLine}).stuck(pc) {
+					applyAction(pc, label, action, flatLinedMark)
+					flatLined = true
+				}
+			}
+			if flatLined {
+				return
+			}
+		}
+
+		if law.MaxPower > 0 && power.Present() && power.Value() > law.MaxPower {
+			applyAction(pc, labels.ShaftPower, action, aboveCeilingMark)
+			return
+		}
+		if law.MaxShaftSpeed > 0 && rpm.Present() && rpm.Value() > law.MaxShaftSpeed {
+			applyAction(pc, labels.ShaftSpeed, action, aboveCeilingMark)
+			return
+		}
+
+		if power.Absent() || rpm.Absent() || rpm.Value() <= modeThresholdsFor(pc.GetShip().ID).IdleShaftSpeed {
+			return
+		}
+		k, n, ok := law.fit(pc, pc.FeatureIndex())
+		if !ok {
+			return
+		}
+		expected := k * math.Pow(rpm.Value(), n)
+		if math.Abs(power.Value()/expected-1) > law.Tolerance {
+			applyAction(pc, labels.ShaftPower, action, offPropellerLawMark)
+			applyAction(pc, labels.ShaftSpeed, action, offPropellerLawMark)
+		}
+	}
+}
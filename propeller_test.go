@@ -0,0 +1,34 @@
+package cleanup
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/nautiluslabsco/ln/shared/constants/labels"
+)
+
+func TestPropellerLawFitSkipsFlaggedPoints(t *testing.T) {
+	power := func(rpm float64) float64 { return 0.01 * math.Pow(rpm, 3) }
+	var features []map[string]float64
+	for rpm := 60.0; rpm < 66; rpm++ {
+		features = append(features, map[string]float64{labels.ShaftSpeed: rpm, labels.ShaftPower: power(rpm)})
+	}
+	// Spikes above the ceiling, flagged but left in place
+	for i := 0; i < 4; i++ {
+		features = append(features, map[string]float64{labels.ShaftSpeed: 70 + float64(i), labels.ShaftPower: 9000})
+	}
+	features = append(features, map[string]float64{labels.ShaftSpeed: 72, labels.ShaftPower: power(72)})
+
+	pc := newFakeCalc(features...)
+	law := PropellerLaw{History: 24 * time.Hour, MinPoints: 5, Tolerance: 0.1, MaxPower: 4000}
+	pc.run(PropellerLawCheck(law, FlagAction))
+
+	last := len(features) - 1
+	if marked(pc, labels.ShaftPower, offPropellerLawMark, last) {
+		t.Error("power on the propeller law was flagged off it")
+	}
+	if !marked(pc, labels.ShaftPower, aboveCeilingMark, last-1) {
+		t.Error("power above the ceiling wasn't flagged")
+	}
+}
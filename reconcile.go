@@ -0,0 +1,192 @@
+package cleanup
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/jinzhu/now"
+	"github.com/nautiluslabsco/ln/shared/constants/labels"
+)
+
+type ReconcileKind string
+
+const (
+	// Sensor rate integrated over the noon period against the noon total
+	ReconcileTotal ReconcileKind = "total"
+	// Sensor mean over the noon period against the noon value
+	ReconcileMean ReconcileKind = "mean"
+	// Sensor position at the report time against the noon position
+	ReconcilePosition ReconcileKind = "position"
+)
+
+// Sensor data covering less of a noon period than this isn't reconciled
+const minReconcileCoverage = 0.9
+
+type ReconciliationCheck struct {
+	Label     string // Unused for positions
+	Kind      ReconcileKind
+	Tolerance float64 // Relative difference, or nautical miles for positions
+}
+
+// ReconciliationPoint holds the sensor and noon report values at one time
+type ReconciliationPoint struct {
+	Time   time.Time
+	Values map[string]float64
+}
+
+type Discrepancy struct {
+	ShipID     int64
+	Label      string
+	Kind       ReconcileKind
+	Start      time.Time
+	End        time.Time
+	Sensor     float64
+	Noon       float64
+	Difference float64 // Relative, or nautical miles for positions
+	SignError  bool    // The positions match once hemispheres are ignored
+	Rule       string  // Drafted cleanup rule, empty when there's no obvious fix
+}
+
+func (d Discrepancy) String() string {
+	return fmt.Sprintf("ship %d %s %s to %s: sensor %.4g, noon %.4g (%.3g)", d.ShipID, d.Label,
+		d.Start.Format(time.RFC3339), d.End.Format(time.RFC3339), d.Sensor, d.Noon, d.Difference)
+}
+
+// Reconcile compares sensor values with the noon reports over each
+// noon-to-noon period, returning the periods that disagree by more than
+// each check's tolerance. The points must be in time order.
+func Reconcile(shipID int64, points []ReconciliationPoint, checks []ReconciliationCheck) []Discrepancy {
+	var discrepancies []Discrepancy
+	for _, check := range checks {
+		noonLabel := labels.Noon(check.Label)
+		if check.Kind == ReconcilePosition {
+			noonLabel = labels.Noon(latitudeLabel)
+		}
+		prev := -1
+		for i, point := range points {
+			if _, ok := point.Values[noonLabel]; !ok {
+				continue
+			}
+			if prev >= 0 && point.Time.Sub(points[prev].Time) <= maxNoonPeriod {
+				if d, ok := check.reconcile(points[prev].Time, points[prev+1:i+1]); ok {
+					d.ShipID = shipID
+					d.Rule = d.draftRule()
+					discrepancies = append(discrepancies, d)
+				}
+			}
+			prev = i
+		}
+	}
+	return discrepancies
+}
+
+// reconcile checks the noon period from start, the last point being the
+// report. Each point's value is taken to hold since the point before it.
+func (check ReconciliationCheck) reconcile(start time.Time, period []ReconciliationPoint) (Discrepancy, bool) {
+	report := period[len(period)-1]
+	d := Discrepancy{Label: check.Label, Kind: check.Kind, Start: start, End: report.Time}
+
+	if check.Kind == ReconcilePosition {
+		sensor, sensorOk := pointPosition(report.Values, latitudeLabel, longitudeLabel)
+		noon, noonOk := pointPosition(report.Values, labels.Noon(latitudeLabel), labels.Noon(longitudeLabel))
+		if !sensorOk || !noonOk {
+			return d, false
+		}
+		d.Label, d.Difference = "Position", DistanceNM(sensor, noon)
+		d.Sensor, d.Noon = sensor.Latitude, noon.Latitude
+		for _, candidate := range signCandidates(sensor) {
+			d.SignError = d.SignError || SamePosition(candidate, noon, check.Tolerance)
+		}
+		return d, d.Difference > check.Tolerance
+	}
+
+	var integral, covered float64
+	since := start
+	for _, point := range period {
+		hours := point.Time.Sub(since).Hours()
+		since = point.Time
+		if v, ok := point.Values[check.Label]; ok {
+			integral += v * hours
+			covered += hours
+		}
+	}
+	total := report.Time.Sub(start).Hours()
+	if covered == 0 || covered < minReconcileCoverage*total {
+		return d, false
+	}
+	d.Noon = report.Values[labels.Noon(check.Label)]
+	if check.Kind == ReconcileTotal {
+		d.Sensor = integral * total / covered
+	} else {
+		d.Sensor = integral / covered
+	}
+	d.Difference = math.Abs(d.Sensor-d.Noon) / math.Max(math.Abs(d.Noon), 1e-9)
+	return d, d.Difference > check.Tolerance
+}
+
+func pointPosition(values map[string]float64, latLabel, lonLabel string) (LatLon, bool) {
+	lat, latOk := values[latLabel]
+	lon, lonOk := values[lonLabel]
+	return LatLon{Latitude: lat, Longitude: lon}, latOk && lonOk
+}
+
+// draftRule suggests a fix for the common causes: sign errors in positions,
+// noon values out by a power of ten, and sensor totals that can't be trusted
+func (d Discrepancy) draftRule() string {
+	comment := fmt.Sprintf("Sensor and noon %s disagree", d.Label)
+	switch {
+	case d.SignError:
+		return draftRule(comment+" in sign", d.ShipID, d.Start, d.End, PostVesselAnatomyStage, "OverrideLatLonSign")
+	case d.Kind == ReconcilePosition || d.Sensor == 0 || d.Noon/d.Sensor <= 0:
+		return ""
+	}
+	if shift := math.Round(math.Log10(d.Noon / d.Sensor)); shift != 0 && math.Abs(d.Noon/d.Sensor/math.Pow(10, shift)-1) < 0.25 {
+		return draftRule(comment+" by a power of ten", d.ShipID, d.Start, d.End, PostVesselAnatomyStage,
+			fmt.Sprintf("RescaleDecimalShift(%q, 72*time.Hour, 0.25)", d.Label))
+	}
+	if d.Kind == ReconcileTotal {
+		return draftRule(comment+", fall back to the noon report", d.ShipID, d.Start, d.End, PostVesselAnatomyStage,
+			fmt.Sprintf(`func(pc calcapi.PropertyCalc) {
+			calc.SetNull(%q)(pc)
+			NoonFallback(NoonFamily{Totals: []string{%q}})(pc)
+		}`, d.Label, d.Label))
+	}
+	return ""
+}
+
+// ReadReconciliationCSV reads points from a CSV export with a time column
+// followed by one column per label, leaving empty cells out
+func ReadReconciliationCSV(r io.Reader) ([]ReconciliationPoint, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	header := records[0]
+	points := make([]ReconciliationPoint, 0, len(records)-1)
+	for line, record := range records[1:] {
+		t, err := now.ParseInLocation(time.UTC, record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line+2, err)
+		}
+		point := ReconciliationPoint{Time: t, Values: map[string]float64{}}
+		for i := 1; i < len(record) && i < len(header); i++ {
+			if record[i] == "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(record[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d, %s: %w", line+2, header[i], err)
+			}
+			point.Values[header[i]] = v
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
@@ -0,0 +1,81 @@
+package cleanup
+
+import (
+	"math"
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	"github.com/nautiluslabsco/ln/shared/constants/labels"
+	log "github.com/sirupsen/logrus"
+)
+
+const inconsistentWithPositionsMark = "Inconsistent With Positions"
+
+type SOGCheck struct {
+	Tolerance float64       // knots
+	MinRun    time.Duration // How long the disagreement must last to be acted on
+	// Replace the sensor SOG with the one computed from positions where it
+	// disagrees or is missing
+	Substitute bool
+}
+
+// usablePosition skips held positions, which would make the ship look stopped
+func usablePosition(pc calcapi.PropertyCalc, idx int) (LatLon, bool) {
+	p, ok := featurePosition(pc, idx)
+	if !ok || !validCoordinates(p) || substituted(pc, positionMark, idx) {
+		return LatLon{}, false
+	}
+	return p, true
+}
+
+// computedSOGAt is the speed over the positions either side of idx, or
+// between idx and whichever neighbour has one
+func computedSOGAt(pc calcapi.PropertyCalc, idx int) (float64, bool) {
+	first, last := idx, idx
+	if _, ok := usablePosition(pc, idx-1); ok {
+		first = idx - 1
+	}
+	if _, ok := usablePosition(pc, idx+1); ok {
+		last = idx + 1
+	}
+	a, aOk := usablePosition(pc, first)
+	b, bOk := usablePosition(pc, last)
+	if first == last || !aOk || !bOk {
+		return 0, false
+	}
+	sog := DistanceNM(a, b) / hoursBetween(pc, first, last)
+	return sog, sog <= maxPlausibleSpeedKnots
+}
+
+// disagrees goes by the original SOG, so a run is still found once the
+// features at its head have been nulled or substituted
+func (c SOGCheck) disagrees(pc calcapi.PropertyCalc, idx int) bool {
+	sog, sogOk := originalValue(pc, labels.SpeedOverGround, idx)
+	computed, ok := computedSOGAt(pc, idx)
+	return sogOk && ok && math.Abs(sog-computed) > c.Tolerance
+}
+
+// SOGConsistency compares the sensor SOG with the speed computed from
+// consecutive positions, applying the action where they disagree for at
+// least MinRun. It's an automatic alternative to aliasing ObservedSpeed
+// over a dead SOG sensor.
+func SOGConsistency(check SOGCheck, action Action) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		computed, ok := computedSOGAt(pc, pc.FeatureIndex())
+		if !ok {
+			return
+		}
+		if pc.GetNullableProperty(labels.SpeedOverGround).Present() {
+			if !check.disagrees(pc, pc.FeatureIndex()) || !sustained(pc, check.MinRun, func(idx int) bool { return check.disagrees(pc, idx) }) {
+				return
+			}
+			log.Debugf("SOG disagrees with positions on %s for ship %d", pc.Time().Format(time.RFC3339), pc.GetShip().ID)
+			applyAction(pc, labels.SpeedOverGround, action, inconsistentWithPositionsMark)
+		}
+		if check.Substitute {
+			recordOriginal(pc, labels.SpeedOverGround)
+			pc.SetProperty(labels.SpeedOverGround, computed)
+			markPoint(pc, labels.SpeedOverGround, substitutedMark)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package cleanup
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/nautiluslabsco/ln/shared/constants/labels"
+)
+
+func TestSOGConsistencySubstitutesWholeRun(t *testing.T) {
+	// Steaming east along the equator at 10 knots with the SOG sensor
+	// reading zero for most of it
+	sog := []float64{10, 10, 0, 0, 0, 0, 0, 0, 10, 10}
+	var features []map[string]float64
+	for i, v := range sog {
+		features = append(features, map[string]float64{
+			latitudeLabel:          0,
+			longitudeLabel:         float64(i) * 10 / 60,
+			labels.SpeedOverGround: v,
+		})
+	}
+	pc := newFakeCalc(features...)
+	pc.run(SOGConsistency(SOGCheck{Tolerance: 2, MinRun: 3 * time.Hour, Substitute: true}, FlagAction))
+
+	for i, v := range sog {
+		got := pc.features[i][labels.SpeedOverGround]
+		if flagged := marked(pc, labels.SpeedOverGround, inconsistentWithPositionsMark, i); flagged != (v == 0) {
+			t.Errorf("feature %d flagged %v", i, flagged)
+		}
+		if math.Abs(got-10) > 0.1 {
+			t.Errorf("feature %d SOG %v, want about 10", i, got)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package cleanup
+
+import (
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	log "github.com/sirupsen/logrus"
+)
+
+const stuckMark = "Stuck"
+
+// StuckSensor is the flat-line threshold for one label. When related
+// labels are given, a run only counts as stuck if one of them changes
+// during it, so a ship sitting idle in port isn't flagged.
+type StuckSensor struct {
+	Label   string
+	MinRun  time.Duration
+	Related []string
+}
+
+func (s StuckSensor) stuck(pc calcapi.PropertyCalc) bool {
+	first, last, ok := unchangedRunBounds(pc, s.Label, s.MinRun)
+	if !ok || featureTime(pc, last).Sub(featureTime(pc, first)) < s.MinRun {
+		return false
+	}
+	if len(s.Related) == 0 {
+		return true
+	}
+	for _, related := range s.Related {
+		if changedBetween(pc, related, first, last) {
+			return true
+		}
+	}
+	return false
+}
+
+// StuckSensorDetector flags, or nulls, runs of unchanged values that last
+// longer than each label's threshold
+func StuckSensorDetector(sensors []StuckSensor, action Action) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		for _, sensor := range sensors {
+			if sensor.stuck(pc) {
+				log.Debugf("%s stuck on %s for ship %d", sensor.Label, pc.Time().Format(time.RFC3339), pc.GetShip().ID)
+				applyAction(pc, sensor.Label, action, stuckMark)
+			}
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package cleanup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStuckSensorDetectorNullsWholeRun(t *testing.T) {
+	stw := []float64{10, 11, 12, 12, 12, 12, 12, 13}
+	var features []map[string]float64
+	for i, v := range stw {
+		features = append(features, map[string]float64{"STW": v, "Shaft Speed": float64(60 + i)})
+	}
+	pc := newFakeCalc(features...)
+	pc.run(StuckSensorDetector([]StuckSensor{{Label: "STW", MinRun: 3 * time.Hour, Related: []string{"Shaft Speed"}}}, NullAction))
+
+	for i, v := range stw {
+		_, present := pc.features[i]["STW"]
+		if stuck := v == 12; present == stuck {
+			t.Errorf("feature %d: present %v, want %v", i, present, !stuck)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package cleanup
+
+import (
+	"math"
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc"
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	"github.com/nautiluslabsco/ln/shared/constants/labels"
+	"github.com/nautiluslabsco/ln/shared/nmath"
+	log "github.com/sirupsen/logrus"
+)
+
+const inconsistentWithCurrentMark = "Inconsistent With Current"
+
+type STWCheck struct {
+	Tolerance float64       // knots
+	MinRun    time.Duration // How long the disagreement must last to be acted on
+}
+
+// expectedSTWAt takes the weather service current along the heading off
+// the SOG, the same projection copernicusModeledSTW uses
+func expectedSTWAt(pc calcapi.PropertyCalc, idx int) (float64, bool) {
+	var values [4]float64
+	for i, label := range []string{labels.SpeedOverGround, string(calc.WS_SeaCurSpeed), string(calc.WS_SeaCurDir), labels.Heading} {
+		v, ok := featureValue(pc, label, idx)
+		if !ok {
+			return 0, false
+		}
+		values[i] = v
+	}
+	sog, curSpeed, curDir, heading := values[0], values[1], values[2], values[3]
+	return sog - nmath.ScalarProjection(curSpeed, curDir, heading), true
+}
+
+// disagrees goes by the original STW, so a run is still found once the
+// features at its head have been nulled
+func (c STWCheck) disagrees(pc calcapi.PropertyCalc, idx int) bool {
+	stw, stwOk := originalValue(pc, labels.SpeedThroughWater, idx)
+	expected, ok := expectedSTWAt(pc, idx)
+	return stwOk && ok && math.Abs(stw-expected) > c.Tolerance
+}
+
+// STWPlausibility applies the action to sensor STW where it disagrees with
+// the SOG corrected for the current for at least MinRun
+func STWPlausibility(check STWCheck, action Action) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		if !check.disagrees(pc, pc.FeatureIndex()) {
+			return
+		}
+		if !sustained(pc, check.MinRun, func(idx int) bool { return check.disagrees(pc, idx) }) {
+			return
+		}
+		log.Debugf("STW disagrees with SOG and current on %s for ship %d", pc.Time().Format(time.RFC3339), pc.GetShip().ID)
+		applyAction(pc, labels.SpeedThroughWater, action, inconsistentWithCurrentMark)
+	}
+}
@@ -0,0 +1,114 @@
+package cleanup
+
+import (
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	"github.com/nautiluslabsco/ln/shared/constants/units"
+	"github.com/nautiluslabsco/ln/shared/models"
+	log "github.com/sirupsen/logrus"
+)
+
+// ConflictPolicy decides what happens when the target label already has a value
+type ConflictPolicy string
+
+const (
+	// Always copy the source, nulling the target when the source is missing
+	ReplaceTarget ConflictPolicy = "replace-target"
+	// Copy the source when it has a value
+	PreferSource ConflictPolicy = "prefer-source"
+	// Only fill a missing target
+	KeepTarget ConflictPolicy = "keep-target"
+)
+
+type UnitConversion struct {
+	From units.Unit
+	To   units.Unit
+}
+
+// TagMapping copies a source label to a target label for one ship over a
+// validity window, for when a vessel's tags were renamed or it reports
+// under a different naming scheme
+type TagMapping struct {
+	Comment    string
+	Issue      string
+	ShipID     int64
+	Start      time.Time
+	End        time.Time
+	Stage      Stage
+	Source     string
+	Target     string
+	Conversion *UnitConversion
+	// Unit to record the target in when there's no Conversion, which
+	// records it in the unit converted to
+	Unit     *units.Unit
+	Conflict ConflictPolicy
+	// When set, Source and Target are templates mapped for each of the
+	// ship's components of this kind in its vessel anatomy, with {n} the
+	// component number. Needs Stage to be PostVesselAnatomyStage.
+	Component ComponentKind
+}
+
+func (m TagMapping) apply(pc calcapi.PropertyCalc) {
+	if m.Component == "" {
+		m.applyLabels(pc, m.Source, m.Target)
+		return
+	}
+	ForEachComponent(m.Component, func(pc calcapi.PropertyCalc, n int) {
+		m.applyLabels(pc, ComponentLabel(m.Source, n), ComponentLabel(m.Target, n))
+	})(pc)
+}
+
+func (m TagMapping) applyLabels(pc calcapi.PropertyCalc, source, target string) {
+	v := pc.GetNullableProperty(source)
+	if v.Absent() {
+		if m.Conflict == ReplaceTarget {
+			pc.SetNullableProperty(target, models.NullValue())
+		}
+		return
+	}
+	if m.Conflict == KeepTarget && pc.GetNullableProperty(target).Present() {
+		return
+	}
+	if m.Conversion == nil {
+		if m.Unit != nil {
+			pc.SetPropertyWithUnit(target, v.Value(), m.Unit.String())
+			return
+		}
+		pc.SetNullableProperty(target, v)
+		return
+	}
+	converted, err := units.Convert(v.Value(), m.Conversion.From.Id, m.Conversion.To.Id)
+	if err != nil {
+		log.Debugf("Unable to convert %s to %s for ship %d: %v", source, target, m.ShipID, err)
+		return
+	}
+	pc.SetPropertyWithUnit(target, converted, m.Conversion.To.String())
+}
+
+func (m TagMapping) cleanupFunc() CleanupFunc {
+	if m.Component != "" && m.Stage != PostVesselAnatomyStage {
+		panic("Per component tag mapping run before the vessel anatomy is loaded")
+	}
+	return CleanupFunc{
+		Comment:       m.Comment,
+		Issue:         m.Issue,
+		ShipID:        m.ShipID,
+		Start:         m.Start,
+		End:           m.End,
+		Unconditional: true,
+		Stage:         m.Stage,
+		CalcFunc:      m.apply,
+	}
+}
+
+// TagMappings lists the mappings configured for a ship
+func TagMappings(shipID int64) []TagMapping {
+	var mappings []TagMapping
+	for _, m := range tagMappings {
+		if m.ShipID == shipID {
+			mappings = append(mappings, m)
+		}
+	}
+	return mappings
+}
@@ -0,0 +1,31 @@
+package cleanup
+
+import "testing"
+
+// anatomyCalc is a fakeCalc with the vessel anatomy loaded
+type anatomyCalc struct {
+	*fakeCalc
+	components map[ComponentKind][]int
+}
+
+func (c anatomyCalc) ComponentNumbers(kind ComponentKind) []int { return c.components[kind] }
+
+func TestTagMappingForEachComponent(t *testing.T) {
+	m := TagMapping{Source: "M/G {n} Power", Target: "Generator {n} Power", Conflict: PreferSource, Component: Generators}
+	features := map[string]float64{"M/G 1 Power": 100, "M/G 5 Power": 500, "M/G 6 Power": 600}
+
+	pc := newFakeCalc(features)
+	m.apply(anatomyCalc{pc, map[ComponentKind][]int{Generators: {1, 5}}})
+	for label, want := range map[string]bool{"Generator 1 Power": true, "Generator 5 Power": true, "Generator 6 Power": false} {
+		if _, ok := pc.features[0][label]; ok != want {
+			t.Errorf("%s mapped %v, want %v", label, ok, want)
+		}
+	}
+
+	// Without the anatomy no component is guessed at
+	pc = newFakeCalc(map[string]float64{"M/G 1 Power": 100})
+	m.apply(pc)
+	if v, ok := pc.features[0]["Generator 1 Power"]; ok {
+		t.Errorf("mapped %v without the vessel anatomy", v)
+	}
+}
@@ -0,0 +1,117 @@
+package cleanup
+
+import (
+	"math"
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	"github.com/nautiluslabsco/ln/shared/models"
+)
+
+const (
+	rolloverMark  = "Rollover"
+	resetMark     = "Reset"
+	meterSwapMark = "Meter Swap"
+)
+
+// Readings further apart than this aren't stitched together
+const totalizerGap = 6 * time.Hour
+
+// Totalizer describes a cumulative flow meter and the labels to write the
+// stitched counter and the rate derived from it to
+type Totalizer struct {
+	Counter  string  // Raw cumulative reading
+	Stitched string  // Continuous counter with rollovers, resets and swaps removed
+	Rate     string  // Counter units per hour, optional
+	Rollover float64 // Value the counter wraps to zero at, 0 if it doesn't
+	MaxRate  float64 // Largest plausible increase per hour, anything bigger is a meter swap
+}
+
+// increment is how much the meter really counted between two readings,
+// along with the mark for whatever discontinuity was found
+func (t Totalizer) increment(prev, cur, hours float64) (float64, string) {
+	limit := math.Inf(1)
+	if t.MaxRate > 0 {
+		limit = t.MaxRate * hours
+	}
+	delta := cur - prev
+	switch {
+	case delta >= 0 && delta <= limit:
+		return delta, ""
+	case delta < 0 && t.Rollover > 0 && t.Rollover-prev+cur <= limit:
+		return t.Rollover - prev + cur, rolloverMark
+	case delta < 0 && cur <= limit:
+		return cur, resetMark
+	default:
+		// A new meter starts from an arbitrary reading, so nothing is
+		// known about the flow across the swap
+		return 0, meterSwapMark
+	}
+}
+
+// StitchTotalizer carries the stitched counter on from the previous reading
+// by the amount the meter counted since, so rollovers at the counter's
+// maximum, resets to zero and steps at meter swaps don't show up as
+// negative or huge consumption. The raw counter is left as it is.
+func StitchTotalizer(t Totalizer) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		idx := pc.FeatureIndex()
+		cur, ok := featureValue(pc, t.Counter, idx)
+		if !ok {
+			return
+		}
+
+		prevIdx, prev, found := idx, 0.0, false
+		for i := idx - 1; withinSpan(pc, i, totalizerGap); i-- {
+			if prev, found = featureValue(pc, t.Counter, i); found {
+				prevIdx = i
+				break
+			}
+		}
+		if !found {
+			t.resume(pc, cur)
+			return
+		}
+
+		hours := hoursBetween(pc, prevIdx, idx)
+		increment, event := t.increment(prev, cur, hours)
+		if event != "" {
+			markPoint(pc, t.Counter, event)
+		}
+		stitched, ok := featureValue(pc, t.Stitched, prevIdx)
+		if !ok {
+			stitched = prev
+		}
+		pc.SetProperty(t.Stitched, stitched+increment)
+		switch {
+		case t.Rate == "":
+		case event == meterSwapMark:
+			// Nothing is known about the flow across a swap
+			pc.SetNullableProperty(t.Rate, models.NullValue())
+		default:
+			pc.SetProperty(t.Rate, increment/hours)
+		}
+	}
+}
+
+// resume continues the stitched counter after an outage, keeping the offset
+// it had from the raw counter before it. If the raw counter went backwards
+// during the outage the meter was reset or swapped, so the stitched counter
+// carries on from where it was.
+func (t Totalizer) resume(pc calcapi.PropertyCalc, cur float64) {
+	for i := pc.FeatureIndex() - 1; hasFeature(pc, i); i-- {
+		stitched, ok := featureValue(pc, t.Stitched, i)
+		if !ok {
+			continue
+		}
+		raw, ok := featureValue(pc, t.Counter, i)
+		if !ok || cur < raw {
+			markPoint(pc, t.Counter, meterSwapMark)
+			pc.SetProperty(t.Stitched, stitched)
+			return
+		}
+		pc.SetProperty(t.Stitched, stitched+cur-raw)
+		return
+	}
+	pc.SetProperty(t.Stitched, cur)
+}
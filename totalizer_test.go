@@ -0,0 +1,59 @@
+package cleanup
+
+import "testing"
+
+func TestStitchTotalizer(t *testing.T) {
+	totalizer := Totalizer{Counter: "FM", Stitched: "FM Stitched", Rate: "FM Rate", Rollover: 1000, MaxRate: 20}
+	tests := []struct {
+		name     string
+		counter  []float64 // -1 for no reading
+		stitched []float64
+		rate     []float64 // -1 for no rate
+	}{
+		{
+			name:     "rollover",
+			counter:  []float64{980, 995, 5, 15},
+			stitched: []float64{980, 995, 1005, 1015},
+			rate:     []float64{-1, 15, 10, 10},
+		},
+		{
+			name:     "meter swap",
+			counter:  []float64{100, 110, 5000, 5010},
+			stitched: []float64{100, 110, 110, 120},
+			rate:     []float64{-1, 10, -1, 10},
+		},
+		{
+			name:     "outage after a rollover",
+			counter:  []float64{995, 5, -1, -1, -1, -1, -1, -1, -1, 50},
+			stitched: []float64{995, 1005, -1, -1, -1, -1, -1, -1, -1, 1050},
+			rate:     []float64{-1, 10, -1, -1, -1, -1, -1, -1, -1, -1},
+		},
+		{
+			name:     "reset during an outage",
+			counter:  []float64{300, 310, -1, -1, -1, -1, -1, -1, -1, 4},
+			stitched: []float64{300, 310, -1, -1, -1, -1, -1, -1, -1, 310},
+			rate:     []float64{-1, 10, -1, -1, -1, -1, -1, -1, -1, -1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var features []map[string]float64
+			for _, v := range tt.counter {
+				f := map[string]float64{}
+				if v >= 0 {
+					f["FM"] = v
+				}
+				features = append(features, f)
+			}
+			pc := newFakeCalc(features...).run(StitchTotalizer(totalizer))
+			for i := range tt.counter {
+				for label, want := range map[string]float64{"FM Stitched": tt.stitched[i], "FM Rate": tt.rate[i]} {
+					got, ok := pc.features[i][label]
+					if want < 0 && ok || want >= 0 && (!ok || got != want) {
+						t.Errorf("feature %d %s: got %v %v, want %v", i, label, got, ok, want)
+					}
+				}
+			}
+		})
+	}
+}
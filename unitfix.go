@@ -0,0 +1,212 @@
+package cleanup
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/nautiluslabsco/ln/features/calc/calcapi"
+	"github.com/nautiluslabsco/ln/shared/constants/units"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	unitCorrectedMark = "Unit Corrected"
+	unitSuspectMark   = "Unit Suspect"
+)
+
+// ConvertUnit corrects a label that arrived in the wrong unit
+func ConvertUnit(label string, from, to units.Unit) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		v := pc.GetNullableProperty(label)
+		if v.Absent() {
+			return
+		}
+		converted, err := units.Convert(v.Value(), from.Id, to.Id)
+		if err != nil {
+			log.Debugf("Unable to convert %s from %s to %s: %v", label, from.String(), to.String(), err)
+			return
+		}
+		pc.SetPropertyWithUnit(label, converted, to.String())
+		markPoint(pc, label, unitCorrectedMark)
+	}
+}
+
+// ScaleBy multiplies a label by factor, for unit errors the units package
+// has no conversion for
+func ScaleBy(label string, factor float64) func(calcapi.PropertyCalc) {
+	return func(pc calcapi.PropertyCalc) {
+		if v := pc.GetNullableProperty(label); v.Present() {
+			pc.SetProperty(label, v.Value()*factor)
+			markPoint(pc, label, unitCorrectedMark)
+		}
+	}
+}
+
+// UnitFactor is how far out a value recorded in the wrong unit is, as the
+// recorded value over the correct one
+type UnitFactor struct {
+	Name   string
+	Factor float64
+}
+
+var (
+	KiloWattsAsMegaWatts = UnitFactor{Name: "kW recorded as MW", Factor: 1e-3}
+	MegaWattsAsKiloWatts = UnitFactor{Name: "MW recorded as kW", Factor: 1e3}
+	KilogramsAsTonnes    = UnitFactor{Name: "kg/h recorded as t/h", Factor: 1e3}
+	TonnesAsKilograms    = UnitFactor{Name: "t/h recorded as kg/h", Factor: 1e-3}
+)
+
+// VolumeAsMass is a volume flow in m³/h recorded as a mass flow in t/h,
+// for a fuel of the given density in t/m³. Fuel densities put it within
+// 20% of 1, so it can only be told from normal variation with a tight
+// tolerance against a reference sensor.
+func VolumeAsMass(density float64) UnitFactor {
+	return UnitFactor{Name: fmt.Sprintf("m³/h recorded as t/h at %.3f t/m³", density), Factor: 1 / density}
+}
+
+// distinguishable reports whether a value off by the factor can't also be
+// within tolerance of the right value
+func (f UnitFactor) distinguishable(tolerance float64) bool {
+	return f.Factor*(1-tolerance) > 1+tolerance || f.Factor*(1+tolerance) < 1-tolerance
+}
+
+// unitErrorAt compares label with reference, or with its own median over
+// the history before it when reference is empty, returning the factor it's
+// off by. Going by the corrected values of the suspect points before it,
+// a run longer than history is still caught.
+func unitErrorAt(pc calcapi.PropertyCalc, label, reference string, history time.Duration, tolerance float64, factors []UnitFactor) (UnitFactor, bool) {
+	v := pc.GetNullableProperty(label)
+	if v.Absent() || v.Value() == 0 {
+		return UnitFactor{}, false
+	}
+	var ref float64
+	if reference != "" {
+		r := pc.GetNullableProperty(reference)
+		if r.Absent() || r.Value() == 0 {
+			return UnitFactor{}, false
+		}
+		ref = r.Value()
+	} else {
+		var ok bool
+		if ref, ok = ownReference(pc, label, history); !ok {
+			return UnitFactor{}, false
+		}
+	}
+
+	ratio := v.Value() / ref
+	for _, f := range factors {
+		if math.Abs(ratio/f.Factor-1) <= tolerance {
+			return f, true
+		}
+	}
+	return UnitFactor{}, false
+}
+
+// ownReference is the corrected value of the point before when that was
+// suspect, or else the median of label over the history before the current
+// point, leaving out suspect points
+func ownReference(pc calcapi.PropertyCalc, label string, history time.Duration) (float64, bool) {
+	if v, ok := correctedValue(pc, label, pc.FeatureIndex()-1); ok {
+		return v, true
+	}
+	var recent []float64
+	for idx := pc.FeatureIndex() - 1; withinSpan(pc, idx, history); idx-- {
+		if v, ok := featureValue(pc, label, idx); ok && !marked(pc, label, unitSuspectMark, idx) {
+			recent = append(recent, v)
+		}
+	}
+	if len(recent) < 3 {
+		return 0, false
+	}
+	return median(recent), true
+}
+
+// correctedValue is what a point marked suspect should have read
+func correctedValue(pc calcapi.PropertyCalc, label string, idx int) (float64, bool) {
+	factor, suspect := featureValue(pc, cleanupLabel(label, unitSuspectMark), idx)
+	v, ok := featureValue(pc, label, idx)
+	if !suspect || !ok {
+		return 0, false
+	}
+	return v / factor, true
+}
+
+// UnitErrorDetector marks points where label is off by one of the factors,
+// compared with reference or its own median over history, and logs one
+// suggested cleanup rule for each run of them once the run ends. Factors
+// too close to 1 to tell from normal variation at the tolerance are skipped.
+func UnitErrorDetector(label, reference string, history time.Duration, tolerance float64, factors []UnitFactor) func(calcapi.PropertyCalc) {
+	suspectLabel := cleanupLabel(label, unitSuspectMark)
+	var usable []UnitFactor
+	for _, f := range factors {
+		if f.distinguishable(tolerance) {
+			usable = append(usable, f)
+		} else {
+			log.Debugf("Not detecting %s for %s, it's within the %v tolerance of the right value", f.Name, label, tolerance)
+		}
+	}
+	factors = usable
+	return func(pc calcapi.PropertyCalc) {
+		idx := pc.FeatureIndex()
+		f, suspect := unitErrorAt(pc, label, reference, history, tolerance, factors)
+		if suspect {
+			pc.SetProperty(suspectLabel, f.Factor)
+		}
+
+		// A run ends at a value that doesn't match it, or where the next
+		// feature has no value to continue it
+		prev, prevSuspect := featureValue(pc, suspectLabel, idx-1)
+		if prevSuspect && pc.GetNullableProperty(label).Present() && (!suspect || f.Factor != prev) {
+			logUnitErrorRun(pc, label, prev, idx-1, factors)
+		}
+		if _, next := featureValue(pc, label, idx+1); suspect && !next {
+			logUnitErrorRun(pc, label, f.Factor, idx, factors)
+		}
+	}
+}
+
+func logUnitErrorRun(pc calcapi.PropertyCalc, label string, factor float64, last int, factors []UnitFactor) {
+	first := last
+	for {
+		v, ok := featureValue(pc, cleanupLabel(label, unitSuspectMark), first-1)
+		if !ok || v != factor {
+			break
+		}
+		first--
+	}
+	for _, f := range factors {
+		if f.Factor == factor {
+			log.Debugf("%s for ship %d from %s to %s looks like %s, suggested rule:\n%s", label, pc.GetShip().ID,
+				featureTime(pc, first).Format(time.RFC3339), featureTime(pc, last).Format(time.RFC3339), f.Name,
+				suggestedUnitRule(pc, label, f, first, last))
+			return
+		}
+	}
+}
+
+// suggestedUnitRule covers the features first to last, with the window
+// widened by a minute either side since rules exclude their start and end
+func suggestedUnitRule(pc calcapi.PropertyCalc, label string, f UnitFactor, first, last int) string {
+	return draftRule(fmt.Sprintf("%s is %s", label, f.Name), pc.GetShip().ID,
+		featureTime(pc, first).Add(-time.Minute), featureTime(pc, last).Add(time.Minute), PreVesselAnatomyStage,
+		fmt.Sprintf("ScaleBy(%q, %g)", label, 1/f.Factor))
+}
+
+// draftRule formats a cleanupFuncs entry for someone to review and paste in
+func draftRule(comment string, shipID int64, start, end time.Time, stage Stage, calcFunc string) string {
+	stageName := "PreVesselAnatomyStage"
+	if stage == PostVesselAnatomyStage {
+		stageName = "PostVesselAnatomyStage"
+	}
+	return fmt.Sprintf(`	{
+		Comment:       %q,
+		Issue:         "TODO",
+		ShipID:        %d,
+		Start:         parseTime(%q),
+		End:           parseTime(%q),
+		Unconditional: true,
+		Stage:         %s,
+		CalcFunc:      %s,
+	},`, comment, shipID, start.Format("2006-01-02 15:04"), end.Format("2006-01-02 15:04"), stageName, calcFunc)
+}
@@ -0,0 +1,41 @@
+package cleanup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUnitErrorDetector(t *testing.T) {
+	const label = "Generator 1 Power"
+	factors := []UnitFactor{KiloWattsAsMegaWatts, VolumeAsMass(0.9)}
+	tests := []struct {
+		name    string
+		value   float64 // after the first ten hours at 100
+		suspect bool
+	}{
+		{"kW recorded as MW", 0.1, true},
+		{"normal variation", 100 / 0.9, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var features []map[string]float64
+			for i := 0; i < 40; i++ {
+				v := 100.0
+				if i >= 10 {
+					v = tt.value
+				}
+				features = append(features, map[string]float64{label: v})
+			}
+			pc := newFakeCalc(features...)
+			pc.run(UnitErrorDetector(label, "", 6*time.Hour, 0.25, factors))
+
+			// Longer than the history, so only found throughout by
+			// leaving the suspect points out of the reference
+			for i := 10; i < 40; i++ {
+				if got := marked(pc, label, unitSuspectMark, i); got != tt.suspect {
+					t.Fatalf("feature %d suspect %v, want %v", i, got, tt.suspect)
+				}
+			}
+		})
+	}
+}